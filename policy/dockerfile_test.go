@@ -0,0 +1,265 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"bufio"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// instruction is a single Dockerfile instruction.
+type instruction struct {
+	// Command is the instruction's keyword in upper case, e.g. RUN.
+	Command string
+	// Args is everything after the keyword, with continuation lines joined by spaces.
+	Args string
+	// Line is the line the instruction starts on.
+	Line int
+	// Comments are the comment lines between the previous instruction and this one, without the
+	// leading #.
+	Comments []string
+}
+
+// parseDockerfile splits a Dockerfile into its instructions. It understands comments and line
+// continuations with the default escape character, which is all the Dockerfiles in this
+// repository use.
+func parseDockerfile(r io.Reader) ([]instruction, error) {
+	var instructions []instruction
+	var comments []string
+	var current *instruction
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		// Comments and empty lines are allowed between continuation lines, and are dropped.
+		if strings.HasPrefix(text, "#") {
+			if current == nil {
+				comments = append(comments, strings.TrimSpace(strings.TrimPrefix(text, "#")))
+			}
+			continue
+		}
+		if text == "" {
+			continue
+		}
+
+		continued := strings.HasSuffix(text, `\`)
+		text = strings.TrimSpace(strings.TrimSuffix(text, `\`))
+		if current == nil {
+			command, args, _ := strings.Cut(text, " ")
+			current = &instruction{
+				Command:  strings.ToUpper(command),
+				Args:     strings.TrimSpace(args),
+				Line:     line,
+				Comments: comments,
+			}
+			comments = nil
+		} else if text != "" {
+			current.Args += " " + text
+		}
+		if !continued {
+			instructions = append(instructions, *current)
+			current = nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if current != nil {
+		instructions = append(instructions, *current)
+	}
+	return instructions, nil
+}
+
+// download is a file fetched with curl or wget in a RUN instruction.
+type download struct {
+	// URL is the URL as written in the Dockerfile.
+	URL string
+	// Output is the file the download is saved to, as written in the Dockerfile, or empty if it is
+	// written to stdout.
+	Output string
+	// PipedTo is the command that reads the download from stdout, if any.
+	PipedTo string
+}
+
+// shellSeparators split a shell script into the pipelines it runs.
+var shellSeparators = regexp.MustCompile(`&&|\|\||;`)
+
+// shellAssignment matches a command that only sets a shell variable.
+var shellAssignment = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_]*)=(\S*)$`)
+
+// shellVariable matches a reference to a shell variable, e.g. $NAME or ${NAME}.
+var shellVariable = regexp.MustCompile(`\$\{?([A-Za-z_][A-Za-z0-9_]*)\}?`)
+
+// parseDownloads returns the downloads in the shell script of a RUN instruction, and the pipelines
+// of the script. Variables assigned in the script are returned too, so that callers can expand
+// URLs that are built up in steps.
+func parseDownloads(script string) (downloads []download, pipelines []string, vars map[string]string) {
+	vars = map[string]string{}
+	for _, pipeline := range shellSeparators.Split(script, -1) {
+		pipeline = strings.TrimSpace(pipeline)
+		if pipeline == "" {
+			continue
+		}
+		pipelines = append(pipelines, pipeline)
+		if m := shellAssignment.FindStringSubmatch(pipeline); m != nil && !strings.Contains(pipeline, "$(") {
+			vars[m[1]] = unquote(m[2])
+		}
+
+		commands := strings.Split(pipeline, "|")
+		for i, command := range commands {
+			words := strings.Fields(command)
+			for j, word := range words {
+				// Handle command substitutions, e.g. VERSION=$(curl ...).
+				if k := strings.LastIndex(word, "("); k >= 0 {
+					word = word[k+1:]
+				}
+				if word != "curl" && word != "wget" {
+					continue
+				}
+				d := parseDownload(word, words[j+1:])
+				if d.Output == "" && i+1 < len(commands) {
+					d.PipedTo = commandName(commands[i+1])
+				}
+				if d.URL != "" {
+					downloads = append(downloads, d)
+				}
+				break
+			}
+		}
+	}
+	return downloads, pipelines, vars
+}
+
+// parseDownload parses the arguments of a curl or wget command.
+func parseDownload(tool string, args []string) download {
+	var d download
+	remoteName := tool == "wget"
+	for i := 0; i < len(args); i++ {
+		arg := unquote(args[i])
+		switch {
+		// URLs are also built from variables, e.g. "${BASE_URL}/${FILE}".
+		case strings.Contains(arg, "://") || (strings.HasPrefix(arg, "$") && strings.Contains(arg, "/")):
+			d.URL = strings.TrimRight(arg, ")")
+		case arg == "--output" || (tool == "wget" && arg == "--output-document"):
+			if i+1 < len(args) {
+				d.Output = unquote(args[i+1])
+				i++
+			}
+		case arg == "--remote-name":
+			remoteName = true
+		case strings.HasPrefix(arg, "-") && !strings.HasPrefix(arg, "--"):
+			flags := strings.TrimPrefix(arg, "-")
+			output := (tool == "curl" && strings.HasSuffix(flags, "o")) ||
+				(tool == "wget" && strings.HasSuffix(flags, "O"))
+			if tool == "curl" && strings.Contains(flags, "O") {
+				remoteName = true
+			}
+			if tool == "wget" && strings.HasPrefix(flags, "O") && len(flags) > 1 {
+				d.Output = flags[1:]
+			} else if output && i+1 < len(args) {
+				d.Output = unquote(args[i+1])
+				i++
+			}
+		}
+	}
+	if d.Output == "" && remoteName && d.URL != "" {
+		d.Output = path.Base(d.URL)
+	}
+	if d.Output == "-" {
+		d.Output = ""
+	}
+	return d
+}
+
+// commandName returns the program a shell command runs, skipping variable assignments.
+func commandName(command string) string {
+	for _, word := range strings.Fields(command) {
+		if !shellAssignment.MatchString(unquote(word)) {
+			return word
+		}
+	}
+	return ""
+}
+
+// expand replaces $NAME and ${NAME} in s with the values in vars, and leaves unknown variables
+// untouched.
+func expand(s string, vars map[string]string) string {
+	return shellVariable.ReplaceAllStringFunc(s, func(ref string) string {
+		if value, ok := vars[shellVariable.FindStringSubmatch(ref)[1]]; ok {
+			return value
+		}
+		return ref
+	})
+}
+
+func unquote(s string) string {
+	return strings.Trim(s, `"'`)
+}
+
+func TestParseDockerfile(t *testing.T) {
+	t.Parallel()
+
+	instructions, err := parseDockerfile(strings.NewReader(`# syntax = docker/dockerfile:1
+FROM debian:trixie AS base
+
+# renovate: datasource=github-releases depName=pulumi/pulumictl
+ENV PULUMICTL_VERSION v0.0.50
+RUN apt-get update && \
+  # Comments inside a continuation are dropped.
+  apt-get install -y \
+
+  curl
+CMD ["bash"]
+`))
+	require.NoError(t, err)
+	require.Equal(t, []instruction{
+		{Command: "FROM", Args: "debian:trixie AS base", Line: 2, Comments: []string{"syntax = docker/dockerfile:1"}},
+		{
+			Command:  "ENV",
+			Args:     "PULUMICTL_VERSION v0.0.50",
+			Line:     5,
+			Comments: []string{"renovate: datasource=github-releases depName=pulumi/pulumictl"},
+		},
+		{Command: "RUN", Args: "apt-get update && apt-get install -y curl", Line: 6},
+		{Command: "CMD", Args: `["bash"]`, Line: 11},
+	}, instructions)
+}
+
+func TestParseDownloads(t *testing.T) {
+	t.Parallel()
+
+	downloads, pipelines, vars := parseDownloads(`FILE="tool_linux_${TARGETARCH}" && ` +
+		`BASE_URL="https://example.com/releases/v1" && ` +
+		`curl --proto "=https" --fail --location --remote-name "${BASE_URL}/${FILE}" && ` +
+		`curl -fsSLo /tmp/go.tgz https://golang.org/dl/go1.26.0.linux-${TARGETARCH}.tar.gz && ` +
+		`echo "${SHA256} /tmp/go.tgz" | sha256sum -c - && ` +
+		`LATEST=$(curl --silent https://example.com/stable.txt) && ` +
+		`curl -sSL https://install.example.com | PREFIX=/usr/local python3 -`)
+
+	require.Equal(t, []download{
+		{URL: "${BASE_URL}/${FILE}", Output: "${FILE}"},
+		{URL: "https://golang.org/dl/go1.26.0.linux-${TARGETARCH}.tar.gz", Output: "/tmp/go.tgz"},
+		{URL: "https://example.com/stable.txt"},
+		{URL: "https://install.example.com", PipedTo: "python3"},
+	}, downloads)
+	require.Len(t, pipelines, 7)
+	require.Equal(t, "https://example.com/releases/v1/tool_linux_${TARGETARCH}", expand(downloads[0].URL, vars))
+}
@@ -0,0 +1,352 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package policy checks the Dockerfiles in docker/ against the repository's rules for downloads,
+// base images and version pins.
+package policy
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// ruleChecksum requires every architecture-specific download to be verified with sha256sum.
+	ruleChecksum = "checksum"
+	// ruleRemoteScript flags scripts that are piped from the network into a shell or interpreter,
+	// and git repositories cloned at their default branch.
+	ruleRemoteScript = "remote-script"
+	// rulePinnedBase requires base images to be pinned to a digest.
+	rulePinnedBase = "pinned-base"
+	// ruleRenovate requires version ENVs to be annotated for the Renovate regex manager in
+	// renovate.json5.
+	ruleRenovate = "renovate"
+)
+
+// exception allows a violation of a rule. Every exception needs a reason, and exceptions that no
+// longer match a violation fail the test, so that the list stays accurate.
+type exception struct {
+	rule string
+	// file is a path.Match pattern for the Dockerfile, relative to the repository root.
+	file string
+	// subject is what the violation is about: the URL, the image or the ENV name.
+	subject string
+	reason  string
+}
+
+var exceptions = []exception{
+	{
+		rule:    rulePinnedBase,
+		file:    "docker/*/Dockerfile*",
+		subject: "debian:trixie-slim",
+		reason:  "Digest pinning is disabled in renovate.json5, so every build picks up Debian's security updates.",
+	},
+	{
+		rule:    rulePinnedBase,
+		file:    "docker/pulumi/Dockerfile",
+		subject: "debian:trixie",
+		reason:  "Digest pinning is disabled in renovate.json5, so every build picks up Debian's security updates.",
+	},
+	{
+		rule:    rulePinnedBase,
+		file:    "docker/nodejs/Dockerfile*",
+		subject: "node:${LANGUAGE_VERSION}-trixie-slim",
+		reason:  "The tag depends on the LANGUAGE_VERSION build argument, so there is no single digest to pin.",
+	},
+	{
+		rule:    rulePinnedBase,
+		file:    "docker/python/Dockerfile*",
+		subject: "python:${LANGUAGE_VERSION}-slim-trixie",
+		reason:  "The tag depends on the LANGUAGE_VERSION build argument, so there is no single digest to pin.",
+	},
+	{
+		rule:    ruleChecksum,
+		file:    "docker/pulumi/Dockerfile",
+		subject: "https://awscli.amazonaws.com/awscli-exe-linux-${AWSCLI_ARCH}.zip",
+		reason:  "AWS publishes a PGP signature for the installer, not a checksum.",
+	},
+	{
+		rule:    ruleChecksum,
+		file:    "docker/pulumi/Dockerfile",
+		subject: "https://github.com/pulumi/pulumictl/releases/download/${PULUMICTL_VERSION}/pulumictl-${PULUMICTL_VERSION}-linux-${TARGETARCH}.tar.gz",
+		reason: "The name of the checksum file published with pulumictl releases is not confirmed yet. " +
+			"Once it is, verify the archive against it like goreleaser, and remove this exception.",
+	},
+	{
+		rule:    ruleRemoteScript,
+		file:    "docker/*/Dockerfile*",
+		subject: "https://get.pulumi.com/",
+		reason:  "The Pulumi installer, PULUMI_VERSION selects the release it installs.",
+	},
+	{
+		rule:    ruleRemoteScript,
+		file:    "docker/*/Dockerfile*",
+		subject: "https://dot.net/v1/dotnet-install.sh",
+		reason:  "Microsoft's documented way to install .NET channels side by side.",
+	},
+	{
+		rule:    ruleRemoteScript,
+		file:    "docker/pulumi/Dockerfile",
+		subject: "https://raw.githubusercontent.com/helm/helm/master/scripts/get-helm-3",
+		reason:  "Installs the latest helm 3 release and verifies its checksum, but the script itself comes from master.",
+	},
+	{
+		rule:    ruleRemoteScript,
+		file:    "docker/pulumi/Dockerfile",
+		subject: "https://raw.githubusercontent.com/golangci/golangci-lint/master/install.sh",
+		reason:  "golangci-lint's documented installer, GOLANGCI_LINT_VERSION selects the release it installs.",
+	},
+	{
+		rule:    ruleRemoteScript,
+		file:    "docker/*/Dockerfile*",
+		subject: "https://github.com/pyenv/pyenv.git",
+		reason:  "pyenv is installed from its default branch to get the newest Python version definitions.",
+	},
+	{
+		rule:    ruleRemoteScript,
+		file:    "docker/*/Dockerfile*",
+		subject: "https://install.python-poetry.org",
+		reason:  "Poetry's documented installer.",
+	},
+	{
+		rule:    ruleRemoteScript,
+		file:    "docker/*/Dockerfile*",
+		subject: "https://astral.sh/uv/install.sh",
+		reason:  "uv's documented installer.",
+	},
+	{
+		rule:    ruleRemoteScript,
+		file:    "docker/*/Dockerfile*",
+		subject: "https://fnm.vercel.app/install",
+		reason:  "fnm's documented installer.",
+	},
+	{
+		rule:    ruleRemoteScript,
+		file:    "docker/java/Dockerfile.ubi",
+		subject: "https://get.sdkman.io",
+		reason:  "SDKMAN's documented installer, MAVEN_VERSION and GRADLE_VERSION select what it installs.",
+	},
+	{
+		rule:    ruleRenovate,
+		file:    "docker/pulumi/Dockerfile",
+		subject: "GOLANG_VERSION",
+		reason:  "Updated together with GOLANG_AMD64_SHA256 and GOLANG_ARM64_SHA256, which the regex manager can't do.",
+	},
+	{
+		rule:    ruleRenovate,
+		file:    "docker/java/Dockerfile.ubi",
+		subject: "JAVA_VERSION",
+		reason:  "Describes the JDK installed from the UBI repositories, it doesn't select a version.",
+	},
+}
+
+// violation is a finding of TestDockerfilePolicy.
+type violation struct {
+	rule    string
+	file    string
+	line    int
+	subject string
+	message string
+}
+
+func (v violation) String() string {
+	return fmt.Sprintf("%s:%d: [%s] %s", v.file, v.line, v.rule, v.message)
+}
+
+var (
+	// archPattern matches URLs that differ per architecture.
+	archPattern = regexp.MustCompile(`\$\{?[A-Z_]*ARCH\}?|amd64|arm64|x86_64|aarch64`)
+	// checksumFilePattern matches URLs of published checksums, which are verified against rather
+	// than verified themselves.
+	checksumFilePattern = regexp.MustCompile(`(?i)(\.sha256(sum)?|checksums\.txt|SHA256SUMS)$`)
+	// interpreters are the programs that run scripts piped into them.
+	interpreters = map[string]bool{"bash": true, "sh": true, "python": true, "python3": true}
+	// renovateAnnotation is the comment that the Renovate regex manager in renovate.json5 expects
+	// directly above an `ENV <NAME> <version>` line.
+	renovateAnnotation = regexp.MustCompile(`^renovate: datasource=\S+ depName=\S+( extractVersion=\S+)?$`)
+	// gitClone matches a git clone and captures the repository URL.
+	gitClone = regexp.MustCompile(`\bgit clone\b.*?(\S+://\S+)`)
+)
+
+// TestDockerfilePolicy checks every Dockerfile in docker/ against the repository's rules. A
+// violation either has to be fixed or get an exception with a reason.
+func TestDockerfilePolicy(t *testing.T) {
+	t.Parallel()
+
+	files, err := filepath.Glob(filepath.Join("..", "docker", "*", "Dockerfile*"))
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+
+	var violations []violation
+	for _, file := range files {
+		f, err := os.Open(file)
+		require.NoError(t, err)
+		instructions, err := parseDockerfile(f)
+		f.Close()
+		require.NoError(t, err, file)
+
+		rel, err := filepath.Rel("..", file)
+		require.NoError(t, err)
+		violations = append(violations, checkDockerfile(filepath.ToSlash(rel), instructions)...)
+	}
+
+	used := make([]bool, len(exceptions))
+	for _, v := range violations {
+		allowed := false
+		for i, e := range exceptions {
+			if e.rule != v.rule || e.subject != v.subject {
+				continue
+			}
+			if ok, _ := path.Match(e.file, v.file); ok {
+				allowed, used[i] = true, true
+			}
+		}
+		if !allowed {
+			t.Errorf("%s", v)
+		}
+	}
+	for i, e := range exceptions {
+		if !used[i] {
+			t.Errorf("exception for %q in %s doesn't match any violation of rule %s, remove it",
+				e.subject, e.file, e.rule)
+		}
+	}
+}
+
+// checkDockerfile returns the violations in the instructions of the Dockerfile at file.
+func checkDockerfile(file string, instructions []instruction) []violation {
+	var violations []violation
+	report := func(rule string, in instruction, subject, format string, args ...any) {
+		violations = append(violations, violation{
+			rule:    rule,
+			file:    file,
+			line:    in.Line,
+			subject: subject,
+			message: fmt.Sprintf(format, args...),
+		})
+	}
+
+	stages := map[string]bool{}
+	for _, in := range instructions {
+		switch in.Command {
+		case "FROM":
+			var words []string
+			for _, word := range strings.Fields(in.Args) {
+				if !strings.HasPrefix(word, "--") {
+					words = append(words, word)
+				}
+			}
+			if len(words) == 0 {
+				continue
+			}
+			image := words[0]
+			if len(words) == 3 && strings.EqualFold(words[1], "AS") {
+				stages[strings.ToLower(words[2])] = true
+			}
+			if !stages[strings.ToLower(image)] && image != "scratch" && !strings.Contains(image, "@sha256:") {
+				report(rulePinnedBase, in, image, "base image %s is not pinned to a digest", image)
+			}
+
+		case "RUN":
+			downloads, pipelines, vars := parseDownloads(in.Args)
+			for _, d := range downloads {
+				if interpreters[d.PipedTo] {
+					report(ruleRemoteScript, in, d.URL, "%s is piped into %s", d.URL, d.PipedTo)
+				}
+				url := expand(d.URL, vars)
+				if d.Output == "" || checksumFilePattern.MatchString(url) || !archPattern.MatchString(url) {
+					continue
+				}
+				if !isVerified(d.Output, pipelines) {
+					report(ruleChecksum, in, d.URL, "%s is downloaded for a specific architecture without "+
+						"verifying its checksum with sha256sum", d.URL)
+				}
+			}
+			for _, pipeline := range pipelines {
+				if m := gitClone.FindStringSubmatch(pipeline); m != nil && !strings.Contains(pipeline, "--branch") {
+					report(ruleRemoteScript, in, m[1], "%s is cloned at its default branch", m[1])
+				}
+			}
+
+		case "ENV":
+			for _, name := range versionEnvs(in.Args) {
+				annotated := len(in.Comments) > 0 && renovateAnnotation.MatchString(in.Comments[len(in.Comments)-1])
+				switch {
+				case !annotated:
+					report(ruleRenovate, in, name, "%s has no `# renovate:` annotation directly above it", name)
+				case strings.Contains(in.Args, "="):
+					report(ruleRenovate, in, name, "%s must be set with `ENV %s <version>` for the Renovate "+
+						"regex manager to find it", name, name)
+				}
+			}
+		}
+	}
+	return violations
+}
+
+// isVerified reports whether one of the pipelines checks the checksum of file with sha256sum.
+func isVerified(file string, pipelines []string) bool {
+	for _, pipeline := range pipelines {
+		if !strings.Contains(pipeline, "sha256sum") || !strings.Contains(pipeline, file) {
+			continue
+		}
+		for _, word := range strings.Fields(pipeline) {
+			if word == "-c" || word == "--check" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// versionEnvs returns the names of the variables ending in _VERSION that an ENV instruction sets to
+// a literal value. Values that refer to other variables are derived, not pinned.
+func versionEnvs(args string) []string {
+	var names []string
+	fields := strings.Fields(args)
+	if len(fields) == 0 {
+		return nil
+	}
+	if !strings.Contains(fields[0], "=") {
+		// The legacy `ENV <name> <value>` form.
+		name, value, _ := strings.Cut(args, " ")
+		if strings.HasSuffix(name, "_VERSION") && !strings.Contains(value, "$") {
+			names = append(names, name)
+		}
+		return names
+	}
+	for _, pair := range fields {
+		name, value, ok := strings.Cut(pair, "=")
+		if ok && strings.HasSuffix(name, "_VERSION") && !strings.Contains(value, "$") {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func TestVersionEnvs(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, []string{"GO_VERSION"}, versionEnvs("GO_VERSION 1.26.0"))
+	require.Equal(t, []string{"NODE_VERSION"}, versionEnvs(`NODE_VERSION=24 PATH="/usr/local/bin:$PATH"`))
+	require.Empty(t, versionEnvs("GOLANG_VERSION=${GO_VERSION}"))
+	require.Empty(t, versionEnvs(""))
+}
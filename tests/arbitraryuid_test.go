@@ -0,0 +1,172 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package containers
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"math/rand"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// arbitraryUIDEnv is set when the test binary is re-executed as an arbitrary UID.
+const arbitraryUIDEnv = "PULUMI_TEST_ARBITRARY_UID"
+
+// arbitraryUIDCheck is a command that must work for a user without a passwd entry.
+type arbitraryUIDCheck struct {
+	// tool is the program the check needs. Checks for tools that are not installed are skipped.
+	tool string
+	args []string
+}
+
+var arbitraryUIDChecks = []arbitraryUIDCheck{
+	{tool: "pulumi", args: []string{"version"}},
+	{tool: "git", args: []string{"--version"}},
+	{tool: "python", args: []string{"-c", "import ssl, sqlite3, venv"}},
+	{tool: "pip", args: []string{"--version"}},
+	{tool: "uv", args: []string{"--version"}},
+	{tool: "poetry", args: []string{"--version"}},
+	{tool: "node", args: []string{"-e", `require("https")`}},
+	{tool: "npm", args: []string{"--version"}},
+	{tool: "yarn", args: []string{"--version"}},
+	{tool: "pnpm", args: []string{"--version"}},
+	{tool: "dotnet", args: []string{"--list-sdks"}},
+	{tool: "go", args: []string{"version"}},
+	{tool: "java", args: []string{"-version"}},
+	{tool: "mvn", args: []string{"--version"}},
+	{tool: "gradle", args: []string{"--version"}},
+	{tool: "az", args: []string{"version"}},
+	{tool: "aws", args: []string{"--version"}},
+	{tool: "gcloud", args: []string{"version"}},
+}
+
+// TestArbitraryUID runs the tools of the UBI images as a random high UID in group 0, without a
+// passwd entry, the way OpenShift's restricted security context constraints run containers. The
+// test binary re-executes itself as that user, with HOME=/ like the container runtime sets it.
+//
+// Tools have to work from the files they can read as group 0. The directories the tools write to
+// at runtime are reported if group 0 can't write to them, since OpenShift users need them to be
+// group-writable.
+//
+// NOTE: This test is intended to be run inside the container.
+func TestArbitraryUID(t *testing.T) {
+	if os.Getenv(arbitraryUIDEnv) != "" {
+		testAsArbitraryUID(t)
+		return
+	}
+	if !isUBI(t) {
+		t.Skip("Only the UBI images are meant to run on OpenShift")
+	}
+	if os.Getuid() != 0 {
+		t.Skip("Switching to an arbitrary UID requires root")
+	}
+	t.Parallel()
+
+	// OpenShift assigns UIDs from a per-project range, e.g. 1000660000-1000669999.
+	uid := uint32(1000660000 + rand.Intn(10000))
+	t.Logf("Running as UID %d, GID 0", uid)
+
+	// The working directory is owned by root, and writable for group 0, like an OpenShift volume.
+	dir, err := os.MkdirTemp("", "arbitrary-uid-")
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, os.RemoveAll(dir)) })
+	require.NoError(t, os.Chmod(dir, 0o770))
+
+	// Container runtimes set HOME=/ for a UID without a passwd entry, unless the pod sets HOME.
+	var env []string
+	for _, kv := range os.Environ() {
+		if !strings.HasPrefix(kv, "HOME=") {
+			env = append(env, kv)
+		}
+	}
+	env = append(env, "HOME=/", arbitraryUIDEnv+"=true")
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestArbitraryUID$", "-test.v", "-test.count=1")
+	cmd.Dir = dir
+	cmd.Env = env
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Credential: &syscall.Credential{Uid: uid, Gid: 0, Groups: []uint32{}},
+	}
+	out, err := cmd.CombinedOutput()
+	t.Logf("Output as UID %d:\n%s", uid, out)
+	require.NoError(t, err)
+}
+
+// testAsArbitraryUID is the part of TestArbitraryUID that runs as the arbitrary UID.
+func testAsArbitraryUID(t *testing.T) {
+	t.Logf("Running as UID %d, GID %d with HOME=%s", os.Getuid(), os.Getgid(), os.Getenv("HOME"))
+	require.NotZero(t, os.Getuid())
+	require.Zero(t, os.Getgid())
+	_, err := exec.Command("whoami").Output()
+	require.Error(t, err, "the UID is expected to have no passwd entry")
+
+	for _, check := range arbitraryUIDChecks {
+		check := check
+		t.Run(check.tool, func(t *testing.T) {
+			if _, err := exec.LookPath(check.tool); err != nil {
+				t.Skipf("%s is not installed in this image", check.tool)
+			}
+			cmd := exec.Command(check.tool, check.args...)
+			out, err := cmd.CombinedOutput()
+			require.NoError(t, err, "%s failed for a UID without a passwd entry:\n%s", cmd, out)
+		})
+	}
+
+	var readOnly []string
+	for _, dir := range arbitraryUIDWritableDirs() {
+		if _, err := os.Stat(dir); errors.Is(err, fs.ErrNotExist) || errors.Is(err, fs.ErrPermission) {
+			continue
+		}
+		// W_OK | X_OK, to create files in the directory.
+		if err := syscall.Access(dir, 0o2|0o1); err != nil {
+			readOnly = append(readOnly, fmt.Sprintf("%s (%v)", dir, err))
+		}
+	}
+	if len(readOnly) > 0 {
+		t.Logf("Directories that need to be group-writable for an arbitrary UID in group 0:\n%s",
+			strings.Join(readOnly, "\n"))
+	}
+}
+
+// arbitraryUIDWritableDirs returns the directories that tools write to at runtime, e.g. when
+// switching language versions, installing plugins or caching downloads.
+func arbitraryUIDWritableDirs() []string {
+	dirs := []string{
+		"/pulumi",
+		"/usr/local/share/fnm",
+		"/usr/local/share/fnm/aliases",
+		"/usr/local/share/pyenv/shims",
+		"/usr/local/share/pyenv/versions",
+		"/root/.cache/node/corepack",
+		"/root/.sdkman",
+	}
+	for _, name := range []string{"DOTNET_ROOT", "GOPATH", "XDG_CACHE_HOME", "XDG_CONFIG_HOME", "PULUMI_HOME"} {
+		if dir := os.Getenv(name); dir != "" {
+			dirs = append(dirs, dir)
+		}
+	}
+	for i, dir := range dirs {
+		dirs[i] = filepath.Clean(dir)
+	}
+	return dirs
+}
@@ -0,0 +1,155 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package containers
+
+import (
+	"bytes"
+	"debug/elf"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// elfMachines maps the architectures the images are built for to their ELF machine type.
+var elfMachines = map[string]elf.Machine{
+	"amd64": elf.EM_X86_64,
+	"arm64": elf.EM_AARCH64,
+}
+
+// TestBinaryArchitecture checks that every executable in the directories we install binaries into
+// was built for the architecture of the image. Many tools are downloaded for a specific
+// architecture, and a wrong TARGETARCH mapping only shows up when the binary is run.
+//
+// The test binary is compiled for the image's architecture, so runtime.GOARCH is the expected
+// architecture.
+//
+// NOTE: This test is intended to be run inside the container.
+func TestBinaryArchitecture(t *testing.T) {
+	t.Parallel()
+
+	expected, ok := elfMachines[runtime.GOARCH]
+	require.True(t, ok, "unsupported architecture %s", runtime.GOARCH)
+
+	dirs := []string{
+		"/pulumi/bin",
+		"/usr/bin",
+		"/usr/local/bin",
+		"/usr/local/go",
+		"/usr/local/share/fnm/node-versions",
+		"/usr/local/share/pyenv/versions",
+	}
+	if gopath := os.Getenv("GOPATH"); gopath != "" {
+		dirs = append(dirs, filepath.Join(gopath, "bin"))
+	}
+	if dotnetRoot := os.Getenv("DOTNET_ROOT"); dotnetRoot != "" {
+		dirs = append(dirs, dotnetRoot)
+	}
+
+	var checked int
+	var mismatches []string
+	for _, dir := range dirs {
+		if _, err := os.Stat(dir); errors.Is(err, fs.ErrNotExist) {
+			t.Logf("Skipping %s, it does not exist in this image", dir)
+			continue
+		}
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if errors.Is(err, fs.ErrPermission) {
+					t.Logf("Skipping %s: %v", path, err)
+					return nil
+				}
+				return err
+			}
+			if d.IsDir() {
+				// The Go distribution has ELF files for other architectures as test inputs.
+				if d.Name() == "testdata" {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !d.Type().IsRegular() {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			if info.Mode().Perm()&0o111 == 0 {
+				return nil
+			}
+
+			machine, isELF, err := readELFMachine(path)
+			if errors.Is(err, fs.ErrPermission) {
+				t.Logf("Skipping %s: %v", path, err)
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if !isELF {
+				return nil
+			}
+			checked++
+			if machine != expected {
+				mismatches = append(mismatches, path+": "+machine.String())
+			}
+			return nil
+		})
+		require.NoError(t, err, "walking %s", dir)
+	}
+
+	t.Logf("Checked %d ELF executables", checked)
+	require.NotZero(t, checked)
+	require.Empty(t, mismatches, "executables not built for %s (%s):\n%s",
+		runtime.GOARCH, expected, strings.Join(mismatches, "\n"))
+}
+
+// readELFMachine returns the machine type of the executable or shared object at path. Files that
+// are not ELF files, and ELF files that are neither, such as object files, are reported with
+// isELF set to false.
+func readELFMachine(path string) (machine elf.Machine, isELF bool, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, false, err
+	}
+	defer f.Close()
+
+	magic := make([]byte, len(elf.ELFMAG))
+	if _, err := io.ReadFull(f, magic); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return 0, false, nil
+		}
+		return 0, false, err
+	}
+	if !bytes.Equal(magic, []byte(elf.ELFMAG)) {
+		return 0, false, nil
+	}
+
+	file, err := elf.NewFile(f)
+	if err != nil {
+		return 0, false, &fs.PathError{Op: "parse ELF", Path: path, Err: err}
+	}
+	if file.Type != elf.ET_EXEC && file.Type != elf.ET_DYN {
+		return 0, false, nil
+	}
+	return file.Machine, true, nil
+}
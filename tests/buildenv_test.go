@@ -0,0 +1,296 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package containers
+
+import (
+	"debug/elf"
+	"debug/macho"
+	"debug/pe"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestBuildEnvironment checks the tools and the image contract of the provider build environment.
+//
+// The image's ENTRYPOINT, CMD and SHELL can't be observed from inside a container, so CI passes
+// the image configuration in IMAGE_CONFIG, as printed by `docker inspect --format '{{json .Config}}'`.
+//
+// NOTE: This test is intended to be run inside the container.
+func TestBuildEnvironment(t *testing.T) {
+	if !isBuildEnvironment(t) {
+		t.Skip("Only the provider build environment has the provider build tools")
+	}
+	t.Parallel()
+
+	var config struct {
+		Entrypoint []string
+		Cmd        []string
+		Shell      []string
+	}
+	require.NoError(t, json.Unmarshal([]byte(mustEnv(t, "IMAGE_CONFIG")), &config))
+
+	t.Run("Tool versions", func(t *testing.T) {
+		t.Parallel()
+
+		for _, tc := range []struct {
+			tool    string
+			args    []string
+			pin     string
+			version *regexp.Regexp
+		}{
+			{
+				tool:    "pulumictl",
+				args:    []string{"version"},
+				pin:     "PULUMICTL_VERSION",
+				version: regexp.MustCompile(`^v?(\S+)`),
+			},
+			{
+				tool:    "golangci-lint",
+				args:    []string{"version"},
+				pin:     "GOLANGCI_LINT_VERSION",
+				version: regexp.MustCompile(`has version v?(\S+)`),
+			},
+			{
+				tool:    "goreleaser",
+				args:    []string{"--version"},
+				pin:     "GORELEASER_VERSION",
+				version: regexp.MustCompile(`GitVersion:\s+v?(\S+)`),
+			},
+		} {
+			t.Run(tc.tool, func(t *testing.T) {
+				t.Parallel()
+
+				pin := strings.TrimPrefix(mustEnv(t, tc.pin), "v")
+				cmd := exec.Command(tc.tool, tc.args...)
+				out, err := cmd.CombinedOutput()
+				require.NoError(t, err, "%s", out)
+				match := tc.version.FindStringSubmatch(strings.TrimSpace(string(out)))
+				require.NotNil(t, match, "no version in output of %q: %s", cmd.String(), out)
+				require.Equal(t, pin, match[1])
+			})
+		}
+	})
+
+	t.Run("Entrypoint and CMD", func(t *testing.T) {
+		t.Parallel()
+
+		// The base image's entrypoint is `pulumi`, the build environment runs arbitrary commands.
+		require.Empty(t, config.Entrypoint)
+		require.Equal(t, []string{"bash"}, config.Cmd)
+		_, err := exec.LookPath("bash")
+		require.NoError(t, err)
+	})
+
+	t.Run("Shell", func(t *testing.T) {
+		t.Parallel()
+
+		require.Equal(t, []string{"/bin/bash", "-o", "errexit", "-o", "nounset", "-o", "pipefail", "-c"},
+			config.Shell)
+
+		// Run scripts the way RUN instructions run them, to check that the options take effect.
+		for _, tc := range []struct {
+			option string
+			script string
+		}{
+			{option: "errexit", script: "false; echo unreachable"},
+			{option: "nounset", script: "echo ${UNSET_BUILD_ENVIRONMENT_VARIABLE}; echo unreachable"},
+			{option: "pipefail", script: "false | true"},
+		} {
+			args := append(append([]string(nil), config.Shell[1:]...), tc.script)
+			out, err := exec.Command(config.Shell[0], args...).CombinedOutput()
+			require.Error(t, err, "%s is not in effect", tc.option)
+			require.NotContains(t, string(out), "unreachable")
+		}
+	})
+
+	t.Run("Vendored Go module", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		copyTestDataDir(t, filepath.Join("testdata", "buildenv"), dir)
+
+		// Everything the module needs is vendored, so it must build and lint without network
+		// access or a module proxy.
+		env := append(os.Environ(),
+			"GOFLAGS=-mod=vendor",
+			"GOPROXY=off",
+			"GOTOOLCHAIN=local",
+			"GOWORK=off",
+			"GOCACHE="+filepath.Join(dir, ".cache", "go-build"),
+			"GOLANGCI_LINT_CACHE="+filepath.Join(dir, ".cache", "golangci-lint"),
+		)
+		run := func(name string, args ...string) (string, error) {
+			cmd := exec.Command(name, args...)
+			cmd.Dir = dir
+			cmd.Env = env
+			t.Logf("Running %q", cmd.String())
+			out, err := cmd.CombinedOutput()
+			return string(out), err
+		}
+
+		out, err := run("go", "build", "-o", "buildenv", ".")
+		require.NoError(t, err, out)
+		out, err = run(filepath.Join(dir, "buildenv"))
+		require.NoError(t, err, out)
+		require.Equal(t, "hello from the build environment", strings.TrimSpace(out))
+
+		out, err = run("golangci-lint", "run", "./...")
+		require.NoError(t, err, out)
+
+		// An unchecked error must be reported, which shows the linters actually ran.
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "unchecked.go"), []byte(`package main
+
+import "os"
+
+func cleanup() {
+	os.Remove("buildenv")
+}
+`), 0o600))
+		out, err = run("golangci-lint", "run", "./...")
+		require.Error(t, err, out)
+		require.Contains(t, out, "errcheck")
+	})
+}
+
+// TestBuildEnvironmentProviderRelease builds a miniature, vendored provider the way provider
+// repositories do: pulumictl computes the version from git, golangci-lint checks the code and
+// goreleaser cross-compiles it. All of it has to work without network access.
+//
+// NOTE: This test is intended to be run inside the container.
+func TestBuildEnvironmentProviderRelease(t *testing.T) {
+	if !isBuildEnvironment(t) {
+		t.Skip("Only the provider build environment has the provider build tools")
+	}
+	t.Parallel()
+
+	dir := filepath.Join(t.TempDir(), "pulumi-mini")
+	copyTestDataDir(t, filepath.Join("testdata", "miniprovider"), dir)
+
+	env := offlineEnv(t, t.TempDir(),
+		"GOFLAGS=-mod=vendor",
+		"GOPROXY=off",
+		"GOTOOLCHAIN=local",
+		"GOWORK=off",
+		"GOCACHE="+filepath.Join(t.TempDir(), "go-build"),
+		"GOLANGCI_LINT_CACHE="+filepath.Join(t.TempDir(), "golangci-lint"),
+		"GIT_AUTHOR_NAME=Pulumi",
+		"GIT_AUTHOR_EMAIL=bot@pulumi.com",
+		"GIT_COMMITTER_NAME=Pulumi",
+		"GIT_COMMITTER_EMAIL=bot@pulumi.com",
+	)
+	run := func(name string, args ...string) string {
+		cmd := exec.Command(name, args...)
+		cmd.Dir = dir
+		cmd.Env = env
+		t.Logf("Running %q", cmd.String())
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, "%s", out)
+		return string(out)
+	}
+
+	run("git", "init", "--quiet", "--initial-branch", "main")
+	run("git", "add", "--all")
+	run("git", "commit", "--quiet", "--message", "Initial commit")
+	run("git", "tag", "v0.1.0")
+
+	version := strings.TrimSpace(run("pulumictl", "get", "version"))
+	require.True(t, strings.HasPrefix(version, "0.1."), "unexpected version %q", version)
+
+	run("golangci-lint", "run", "./...")
+
+	run("goreleaser", "build", "--snapshot", "--clean")
+
+	var artifacts []struct {
+		Path   string `json:"path"`
+		Goos   string `json:"goos"`
+		Goarch string `json:"goarch"`
+		Type   string `json:"type"`
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "dist", "artifacts.json"))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &artifacts), string(data))
+
+	built := map[string]string{}
+	for _, artifact := range artifacts {
+		if artifact.Type == "Binary" {
+			built[artifact.Goos+"/"+artifact.Goarch] = filepath.Join(dir, artifact.Path)
+		}
+	}
+	for _, target := range []string{
+		"linux/amd64", "linux/arm64",
+		"darwin/amd64", "darwin/arm64",
+		"windows/amd64", "windows/arm64",
+	} {
+		path, ok := built[target]
+		require.True(t, ok, "no binary for %s in %v", target, built)
+		goos, goarch, _ := strings.Cut(target, "/")
+		requireBinaryFor(t, path, goos, goarch)
+	}
+
+	// The snapshot version is stamped into the binary that runs here.
+	cmd := exec.Command(built["linux/"+runtime.GOARCH])
+	out, err := cmd.Output()
+	require.NoError(t, err)
+	var schema struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}
+	require.NoError(t, json.Unmarshal(out, &schema), string(out))
+	require.Equal(t, "mini", schema.Name)
+	require.Equal(t, "0.1.1-dev", schema.Version)
+}
+
+// requireBinaryFor checks that the executable at path was built for goos and goarch.
+func requireBinaryFor(t *testing.T, path, goos, goarch string) {
+	t.Helper()
+
+	switch goos {
+	case "linux":
+		f, err := elf.Open(path)
+		require.NoError(t, err)
+		defer f.Close()
+		require.Equal(t, map[string]elf.Machine{
+			"amd64": elf.EM_X86_64,
+			"arm64": elf.EM_AARCH64,
+		}[goarch], f.Machine, path)
+	case "darwin":
+		f, err := macho.Open(path)
+		require.NoError(t, err)
+		defer f.Close()
+		require.Equal(t, map[string]macho.Cpu{
+			"amd64": macho.CpuAmd64,
+			"arm64": macho.CpuArm64,
+		}[goarch], f.Cpu, path)
+	case "windows":
+		require.Equal(t, ".exe", filepath.Ext(path))
+		f, err := pe.Open(path)
+		require.NoError(t, err)
+		defer f.Close()
+		require.Equal(t, map[string]uint16{
+			"amd64": pe.IMAGE_FILE_MACHINE_AMD64,
+			"arm64": pe.IMAGE_FILE_MACHINE_ARM64,
+		}[goarch], f.Machine, path)
+	default:
+		require.Failf(t, "unexpected GOOS", "%s", goos)
+	}
+}
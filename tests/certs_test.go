@@ -0,0 +1,225 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package containers
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// debianCABundle is the bundle that update-ca-certificates generates on Debian based images.
+const debianCABundle = "/etc/ssl/certs/ca-certificates.crt"
+
+// ubiCABundle is the bundle that update-ca-trust generates on UBI based images.
+const ubiCABundle = "/etc/pki/tls/certs/ca-bundle.crt"
+
+// caStore describes how the image's distribution manages its trusted CAs.
+type caStore struct {
+	// Anchors is the directory for locally trusted CA certificates.
+	Anchors string
+	// Update is the command that rebuilds Bundle from the distribution's and the local CAs.
+	Update []string
+	// Bundle is the PEM bundle of all trusted CAs, which OpenSSL uses by default.
+	Bundle string
+}
+
+// systemCAStore returns the CA store of the image under test.
+func systemCAStore(t *testing.T) caStore {
+	if isUBI(t) {
+		return caStore{
+			Anchors: "/etc/pki/ca-trust/source/anchors",
+			Update:  []string{"update-ca-trust", "extract"},
+			Bundle:  ubiCABundle,
+		}
+	}
+	return caStore{
+		Anchors: "/usr/local/share/ca-certificates",
+		Update:  []string{"update-ca-certificates"},
+		Bundle:  debianCABundle,
+	}
+}
+
+// testCA is a certificate authority for tests that need certificates that chain to a root, like
+// the ones issued by a corporate CA.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	// PEM is the CA's certificate in PEM form.
+	PEM []byte
+}
+
+// newTestCA returns a new root CA.
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          newSerial(t),
+		Subject:               pkix.Name{Organization: []string{"Pulumi"}, CommonName: t.Name() + " Root CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCA{
+		cert: cert,
+		key:  key,
+		PEM:  pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+	}
+}
+
+// Issue returns a TLS server certificate for hosts, signed by the CA.
+func (ca *testCA) Issue(t *testing.T, hosts ...string) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: newSerial(t),
+		Subject:      pkix.Name{CommonName: hosts[0]},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	addHosts(template, hosts)
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	return tls.Certificate{Certificate: [][]byte{der, ca.cert.Raw}, PrivateKey: key}
+}
+
+// newSelfSignedCertificate returns a self-signed TLS certificate that is valid for hosts, along
+// with the certificate in PEM form.
+func newSelfSignedCertificate(t *testing.T, hosts ...string) (tls.Certificate, []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          newSerial(t),
+		Subject:               pkix.Name{CommonName: t.Name()},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	addHosts(template, hosts)
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	require.NoError(t, err)
+	return cert, certPEM
+}
+
+func newSerial(t *testing.T) *big.Int {
+	t.Helper()
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	require.NoError(t, err)
+	return serial
+}
+
+// addHosts adds hosts to the subject alternative names of template.
+func addHosts(template *x509.Certificate, hosts []string) {
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+}
+
+// systemCAs tracks the certificates that trustCertificate added to the system CA store. Updating
+// the store rebuilds the bundle from every anchor, so concurrent tests must not update it at the
+// same time. Anchors are only removed once none of the tests that added one is running anymore,
+// so that no test loses trust in its certificate while it still needs it.
+var systemCAs struct {
+	sync.Mutex
+	users   int
+	anchors []string
+}
+
+// trustCertificate makes processes started by the test trust certPEM, and returns the environment
+// variables those processes need for it.
+//
+// As root, the certificate is added to the image's CA store, the way the distribution documents
+// it, and removed again when the test ends. The nonroot user cannot write to the store, so instead
+// it gets a copy of the image's bundle with the certificate appended, through SSL_CERT_FILE.
+func trustCertificate(t *testing.T, certPEM []byte) []string {
+	t.Helper()
+	store := systemCAStore(t)
+
+	if isNonRoot(t) {
+		bundle, err := os.ReadFile(store.Bundle)
+		require.NoError(t, err)
+		p := filepath.Join(t.TempDir(), filepath.Base(store.Bundle))
+		require.NoError(t, os.WriteFile(p, append(bundle, certPEM...), 0o644))
+		return []string{"SSL_CERT_FILE=" + p}
+	}
+
+	systemCAs.Lock()
+	defer systemCAs.Unlock()
+	p := filepath.Join(store.Anchors, RandomStackName(t)+".crt")
+	require.NoError(t, os.WriteFile(p, certPEM, 0o644))
+	systemCAs.anchors = append(systemCAs.anchors, p)
+	systemCAs.users++
+	t.Cleanup(func() {
+		systemCAs.Lock()
+		defer systemCAs.Unlock()
+		systemCAs.users--
+		if systemCAs.users > 0 {
+			return
+		}
+		for _, anchor := range systemCAs.anchors {
+			require.NoError(t, os.Remove(anchor))
+		}
+		systemCAs.anchors = nil
+		updateCAStore(t, store)
+	})
+	updateCAStore(t, store)
+	return nil
+}
+
+// updateCAStore rebuilds the bundle of the system CA store from its anchors.
+func updateCAStore(t *testing.T, store caStore) {
+	t.Helper()
+	out, err := exec.Command(store.Update[0], store.Update[1:]...).CombinedOutput()
+	require.NoError(t, err, "%s", out)
+}
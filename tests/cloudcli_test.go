@@ -0,0 +1,220 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package containers
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestAzureCLIOffline checks the Azure CLI from the Debian repos without an Azure account, in a
+// fresh HOME like the one a nonroot user gets.
+//
+// NOTE: This test is intended to be run inside the container.
+func TestAzureCLIOffline(t *testing.T) {
+	if !isKitchenSink(t) {
+		t.Skip("The Azure CLI is only installed in the kitchen sink image")
+	}
+	t.Parallel()
+
+	home := t.TempDir()
+	env := offlineEnv(t, home, "AZURE_CORE_COLLECT_TELEMETRY=false")
+
+	stdout, stderr, err := runOffline(t, env, "az", "version", "--output", "json")
+	require.NoError(t, err, stderr)
+	var versions map[string]any
+	require.NoError(t, json.Unmarshal([]byte(stdout), &versions), stdout)
+	require.Contains(t, versions, "azure-cli")
+	require.Contains(t, versions, "azure-cli-core")
+
+	stdout, stderr, err = runOffline(t, env, "az", "extension", "list", "--output", "json")
+	require.NoError(t, err, stderr)
+	var extensions []map[string]any
+	require.NoError(t, json.Unmarshal([]byte(stdout), &extensions), stdout)
+
+	// The CLI keeps its configuration in ~/.azure.
+	_, stderr, err = runOffline(t, env, "az", "config", "set", "core.output=table")
+	require.NoError(t, err, stderr)
+	stdout, stderr, err = runOffline(t, env, "az", "config", "get", "core.output", "--output", "json")
+	require.NoError(t, err, stderr)
+	var option struct {
+		Name   string `json:"name"`
+		Source string `json:"source"`
+		Value  string `json:"value"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(stdout), &option), stdout)
+	require.Equal(t, "table", option.Value)
+	require.Equal(t, filepath.Join(home, ".azure", "config"), option.Source)
+
+	_, stderr, err = runOffline(t, env, "az", "account", "show")
+	require.Error(t, err)
+	requireCleanFailure(t, stderr)
+	require.Contains(t, stderr, "az login")
+}
+
+// TestGCloudOffline checks the Google Cloud CLI from the Google apt repo without a GCP project,
+// with a fresh configuration directory and no network access.
+//
+// NOTE: This test is intended to be run inside the container.
+func TestGCloudOffline(t *testing.T) {
+	if !isKitchenSink(t) {
+		t.Skip("gcloud is only installed in the kitchen sink image")
+	}
+	t.Parallel()
+
+	home := t.TempDir()
+	config := filepath.Join(home, "gcloud")
+	env := offlineEnv(t, home,
+		"CLOUDSDK_CONFIG="+config,
+		"CLOUDSDK_CORE_DISABLE_PROMPTS=1",
+		"CLOUDSDK_CORE_DISABLE_USAGE_REPORTING=true",
+		"CLOUDSDK_COMPONENT_MANAGER_DISABLE_UPDATE_CHECK=true",
+	)
+
+	stdout, stderr, err := runOffline(t, env, "gcloud", "version", "--format=json")
+	require.NoError(t, err, stderr)
+	var versions map[string]any
+	require.NoError(t, json.Unmarshal([]byte(stdout), &versions), stdout)
+	require.Contains(t, versions, "Google Cloud SDK")
+	require.Contains(t, versions, "core")
+
+	// The component manager is disabled for installs from the apt repo, in which case gcloud
+	// must say so rather than crash.
+	stdout, stderr, err = runOffline(t, env, "gcloud", "components", "list", "--only-local-state", "--format=json")
+	if err != nil {
+		requireCleanFailure(t, stderr)
+		require.Contains(t, stderr, "component manager is disabled")
+	} else {
+		var components []map[string]any
+		require.NoError(t, json.Unmarshal([]byte(stdout), &components), stdout)
+		require.NotEmpty(t, components)
+	}
+
+	_, stderr, err = runOffline(t, env, "gcloud", "config", "set", "project", "pulumi-offline")
+	require.NoError(t, err, stderr)
+	stdout, stderr, err = runOffline(t, env, "gcloud", "config", "get", "project")
+	require.NoError(t, err, stderr)
+	require.Equal(t, "pulumi-offline", strings.TrimSpace(stdout))
+	require.FileExists(t, filepath.Join(config, "configurations", "config_default"))
+
+	// Activating a service account key is local, using it requires a token from the network.
+	const email = "offline@pulumi-offline.iam.gserviceaccount.com"
+	keyFile := writeServiceAccountKey(t, home, email)
+	_, stderr, err = runOffline(t, env, "gcloud", "auth", "activate-service-account", "--key-file", keyFile)
+	require.NoError(t, err, stderr)
+	stdout, stderr, err = runOffline(t, env, "gcloud", "auth", "list", "--format=value(account)")
+	require.NoError(t, err, stderr)
+	require.Contains(t, stdout, email)
+
+	_, stderr, err = runOffline(t, env, "gcloud", "auth", "print-access-token")
+	require.Error(t, err)
+	requireCleanFailure(t, stderr)
+	require.Contains(t, stderr, "ERROR: (gcloud.auth.print-access-token)")
+}
+
+// offlineEnv returns an environment for cloud CLIs with HOME set to home, without any cloud
+// credentials or configuration from the test's environment, and with all HTTP traffic sent to a
+// proxy that refuses connections.
+func offlineEnv(t *testing.T, home string, extra ...string) []string {
+	t.Helper()
+
+	// Reserve a port, and close it again so that connecting to it is refused.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	proxy := "http://" + l.Addr().String()
+	require.NoError(t, l.Close())
+
+	var env []string
+	for _, kv := range os.Environ() {
+		name, _, _ := strings.Cut(kv, "=")
+		switch {
+		case name == "HOME",
+			strings.HasPrefix(name, "AZURE_"), strings.HasPrefix(name, "ARM_"),
+			strings.HasPrefix(name, "CLOUDSDK_"), strings.HasPrefix(name, "GOOGLE_"),
+			strings.EqualFold(name, "HTTP_PROXY"), strings.EqualFold(name, "HTTPS_PROXY"),
+			strings.EqualFold(name, "NO_PROXY"):
+			continue
+		}
+		env = append(env, kv)
+	}
+	env = append(env,
+		"HOME="+home,
+		"HTTP_PROXY="+proxy,
+		"HTTPS_PROXY="+proxy,
+		"http_proxy="+proxy,
+		"https_proxy="+proxy,
+	)
+	return append(env, extra...)
+}
+
+// runOffline runs a command with env as its environment. Unlike the other helpers it doesn't fail
+// the test when the command fails, since some commands are expected to.
+func runOffline(t *testing.T, env []string, name string, args ...string) (string, string, error) {
+	t.Helper()
+	cmd := exec.Command(name, args...)
+	cmd.Env = env
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	t.Logf("Running %q", cmd.String())
+	err := cmd.Run()
+	if err != nil {
+		t.Logf("%s failed: %v\nstderr: %s", name, err, stderr.String())
+	}
+	return stdout.String(), stderr.String(), err
+}
+
+// requireCleanFailure checks that a Python based CLI reported an error instead of crashing.
+func requireCleanFailure(t *testing.T, stderr string) {
+	t.Helper()
+	require.NotContains(t, stderr, "Traceback (most recent call last)")
+	require.NotContains(t, stderr, "ModuleNotFoundError")
+	require.NotContains(t, stderr, "ImportError")
+}
+
+// writeServiceAccountKey writes a Google service account key for email, with a freshly generated
+// private key, and returns its path.
+func writeServiceAccountKey(t *testing.T, dir, email string) string {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	data, err := json.Marshal(map[string]string{
+		"type":           "service_account",
+		"project_id":     "pulumi-offline",
+		"private_key_id": "0000000000000000000000000000000000000000",
+		"private_key":    string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		"client_email":   email,
+		"client_id":      "000000000000000000000",
+		"auth_uri":       "https://accounts.google.com/o/oauth2/auth",
+		"token_uri":      "https://oauth2.googleapis.com/token",
+	})
+	require.NoError(t, err)
+	p := filepath.Join(dir, "key.json")
+	require.NoError(t, os.WriteFile(p, data, 0o600))
+	return p
+}
@@ -0,0 +1,115 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package containers
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	ptesting "github.com/pulumi/pulumi/sdk/v3/go/common/testing"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLocalComponents builds a component provider from source in each of TypeScript, Python and
+// Go, generates a local SDK for it with `pulumi package add ./component`, and previews a program
+// that uses the component from every other language. This exercises all of the image's toolchains
+// in a single stack.
+//
+// Java is not a consumer: `pulumi package add` only prints manual instructions for wiring a
+// generated Java SDK into the build.
+//
+// NOTE: This test is intended to be run inside the kitchen sink container.
+func TestLocalComponents(t *testing.T) {
+	if !isKitchenSink(t) {
+		t.Skip("Only test local components on kitchen sink")
+	}
+	t.Parallel()
+
+	components := []struct {
+		language string
+		pkg      string
+	}{
+		{language: "typescript", pkg: "tsgreeter"},
+		{language: "python", pkg: "pygreeter"},
+		{language: "go", pkg: "gogreeter"},
+	}
+	consumers := []string{"typescript", "python", "go", "csharp", "yaml"}
+
+	for _, component := range components {
+		component := component
+		t.Run(component.language, func(t *testing.T) {
+			t.Parallel()
+
+			for _, consumer := range consumers {
+				consumer := consumer
+				if consumer == component.language {
+					continue
+				}
+				t.Run(consumer, func(t *testing.T) {
+					t.Parallel()
+
+					e := ptesting.NewEnvironment(t)
+					defer e.DeleteIfNotFailed()
+					e.SetBackend(e.LocalURL())
+
+					componentDir := filepath.Join(e.RootPath, "component")
+					copyTestDataDir(t, filepath.Join("testdata", "components", "providers", component.language), componentDir)
+					if component.language == "go" {
+						// The fixture does not check in a go.sum, resolve it before Pulumi builds the plugin.
+						e.CWD = componentDir
+						e.RunCommand("go", "mod", "tidy")
+					}
+
+					e.CWD = filepath.Join(e.RootPath, "consumer")
+					copyTestDataDir(t, filepath.Join("testdata", "components", "consumers", consumer), e.CWD)
+					replaceInDir(t, e.CWD, map[string]string{
+						"__PACKAGE__": component.pkg,
+						"__Package__": strings.ToUpper(component.pkg[:1]) + component.pkg[1:],
+					})
+
+					e.RunCommand("pulumi", "package", "add", componentDir)
+					e.RunCommand("pulumi", "stack", "init", "dev")
+					e.RunCommand("pulumi", "install")
+					stdout, _ := e.RunCommand("pulumi", "preview", "--non-interactive")
+					require.Contains(t, stdout, component.pkg+":index:Greeter")
+				})
+			}
+		})
+	}
+}
+
+// replaceInDir replaces every occurrence of the keys of replacements with their values in all
+// files below dir.
+func replaceInDir(t *testing.T, dir string, replacements map[string]string) {
+	t.Helper()
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		content, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		s := string(content)
+		for old, replacement := range replacements {
+			s = strings.ReplaceAll(s, old, replacement)
+		}
+		return os.WriteFile(p, []byte(s), os.ModePerm)
+	})
+	require.NoError(t, err)
+}
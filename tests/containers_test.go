@@ -19,10 +19,13 @@ import (
 	"embed"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -179,6 +182,10 @@ func TestKitchenSinkLanguageVersions(t *testing.T) {
 
 	for _, dir := range dirs {
 		dir := dir
+		if !isProgramDir(t, filepath.Join("testdata", dir.Name())) {
+			// Directories without a Pulumi.yaml group fixtures for other tests.
+			continue
+		}
 		t.Run(dir.Name(), func(t *testing.T) {
 			if dir.Name() == "node-default" {
 				// The `node-default` test is run first, so we skip it here.
@@ -524,25 +531,26 @@ func TestEnvironment(t *testing.T) {
 		// Install scripts for various tools can sometimes modify PATH, usually by adding entries
 		// to ~/.bashrc. This test ensures that we notice such modifications.
 		expectedPaths := map[string]string{
-			"pulumi":               "/pulumi/bin:/usr/local/share/fnm/aliases/default/bin:/usr/local/share/pyenv/shims:/usr/local/share/pyenv/bin:/usr/local/share/dotnet:/go/bin:/usr/local/go/bin:/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin",
-			"pulumi-nonroot":       "/pulumi/bin:/usr/local/share/fnm/aliases/default/bin:/usr/local/share/pyenv/shims:/usr/local/share/pyenv/bin:/usr/local/share/dotnet:/go/bin:/usr/local/go/bin:/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin",
-			"pulumi-debian-dotnet": "/root/.dotnet:/pulumi/bin:/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin",
-			"pulumi-debian-go":     "/pulumi/bin:/go/bin:/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin",
-			"pulumi-debian-java":   "/pulumi/bin:/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin",
-			"pulumi-debian-nodejs": "/pulumi/bin:/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin",
-			"pulumi-debian-python": "/pulumi/bin:/usr/local/bin:/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin",
-			"pulumi-ubi-dotnet":    "/root/.dotnet:/pulumi/bin:/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin",
-			"pulumi-ubi-go":        "/pulumi/bin:/go/bin:/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin",
-			"pulumi-ubi-java":      "/pulumi/bin:/root/.sdkman/candidates/maven/current/bin:/root/.sdkman/candidates/gradle/current/bin:/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin",
-			"pulumi-ubi-nodejs":    "/pulumi/bin:/usr/local/share/fnm/aliases/default/bin:/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin",
-			"pulumi-ubi-python":    "/pulumi/bin:/usr/local/share/pyenv/shims:/usr/local/share/pyenv/bin:/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin",
+			"pulumi":                            "/pulumi/bin:/usr/local/share/fnm/aliases/default/bin:/usr/local/share/pyenv/shims:/usr/local/share/pyenv/bin:/usr/local/share/dotnet:/go/bin:/usr/local/go/bin:/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin",
+			"pulumi-nonroot":                    "/pulumi/bin:/usr/local/share/fnm/aliases/default/bin:/usr/local/share/pyenv/shims:/usr/local/share/pyenv/bin:/usr/local/share/dotnet:/go/bin:/usr/local/go/bin:/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin",
+			"pulumi-provider-build-environment": "/pulumi/bin:/usr/local/share/fnm/aliases/default/bin:/usr/local/share/pyenv/shims:/usr/local/share/pyenv/bin:/usr/local/share/dotnet:/go/bin:/usr/local/go/bin:/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin",
+			"pulumi-debian-dotnet":              "/root/.dotnet:/pulumi/bin:/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin",
+			"pulumi-debian-go":                  "/pulumi/bin:/go/bin:/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin",
+			"pulumi-debian-java":                "/pulumi/bin:/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin",
+			"pulumi-debian-nodejs":              "/pulumi/bin:/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin",
+			"pulumi-debian-python":              "/pulumi/bin:/usr/local/bin:/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin",
+			"pulumi-ubi-dotnet":                 "/root/.dotnet:/pulumi/bin:/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin",
+			"pulumi-ubi-go":                     "/pulumi/bin:/go/bin:/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin",
+			"pulumi-ubi-java":                   "/pulumi/bin:/root/.sdkman/candidates/maven/current/bin:/root/.sdkman/candidates/gradle/current/bin:/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin",
+			"pulumi-ubi-nodejs":                 "/pulumi/bin:/usr/local/share/fnm/aliases/default/bin:/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin",
+			"pulumi-ubi-python":                 "/pulumi/bin:/usr/local/share/pyenv/shims:/usr/local/share/pyenv/bin:/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin",
 		}
 
 		t.Run("PATH when running in bash", func(t *testing.T) {
 			t.Parallel()
 			expectedPath := expectedPaths[imageVariant]
 			// When running in bash, we pick up the PATH entry from the pulumi installation script.
-			if imageVariant == "pulumi" {
+			if isKitchenSink(t) {
 				expectedPath += ":/root/.pulumi/bin"
 			}
 			// When running in bash, the UBI images include /root/.local/bin:/root/bin:
@@ -593,7 +601,7 @@ func TestEnvironment(t *testing.T) {
 	t.Run("Workdir", func(t *testing.T) {
 		t.Parallel()
 		// Kitchen sink does not set `WORKDIR`.
-		if imageVariant == "pulumi" || imageVariant == "pulumi-nonroot" {
+		if isKitchenSink(t) || imageVariant == "pulumi-nonroot" {
 			requireOutput(t, "/", "pwd")
 			requireOutputWithBash(t, "/", "pwd")
 		} else {
@@ -604,27 +612,25 @@ func TestEnvironment(t *testing.T) {
 
 	t.Run("User", func(t *testing.T) {
 		t.Parallel()
-		if isNonRoot(t) {
-			requireOutput(t, "pulumi", "whoami")
-			requireOutputWithBash(t, "pulumi", "whoami")
-		} else {
-			requireOutput(t, "root", "whoami")
-			requireOutputWithBash(t, "root", "whoami")
-		}
+		user := expectedUser(t)
+		requireOutput(t, user.Name, "whoami")
+		requireOutputWithBash(t, user.Name, "whoami")
+		requireOutput(t, strconv.Itoa(user.UID), "id", "-u")
+		requireOutput(t, strconv.Itoa(user.GID), "id", "-g")
 	})
 
 	t.Run("Home", func(t *testing.T) {
 		t.Parallel()
-		if isNonRoot(t) {
-			requireOutput(t, "/home/pulumi", "printenv", "HOME")
-			requireOutputWithBash(t, "/home/pulumi", "printenv", "HOME")
-		} else {
-			requireOutput(t, "/root", "printenv", "HOME")
-			requireOutputWithBash(t, "/root", "printenv", "HOME")
-		}
+		user := expectedUser(t)
+		requireOutput(t, user.Home, "printenv", "HOME")
+		requireOutputWithBash(t, user.Home, "printenv", "HOME")
 	})
 }
 
+// bashEnv is how Deployments configures the bash it runs each step with.
+// https://github.com/pulumi/pulumi-service/blob/8cbd9397ec0cdc7b5c168715ca4c9aa087c83823/cmd/workflow-runner/run.go#L78
+const bashEnv = "BASH_ENV=/root/.bashrc"
+
 func requireOutput(t *testing.T, expected, cmd string, args ...string) {
 	c := exec.Command(cmd, args...)
 	t.Logf("Running %q", c.String())
@@ -637,7 +643,7 @@ func requireOutput(t *testing.T, expected, cmd string, args ...string) {
 func requireOutputWithBash(t *testing.T, expected, cmd string, args ...string) {
 	bashArgs := strings.Join(append([]string{cmd}, args...), " ")
 	c := exec.Command("/bin/bash", "-c", bashArgs)
-	c.Env = append(os.Environ(), "BASH_ENV=/root/.bashrc")
+	c.Env = append(os.Environ(), bashEnv)
 	t.Logf("Running %q", c.String())
 	out, err := c.Output()
 	require.NoError(t, err)
@@ -654,9 +660,22 @@ func mustEnv(t *testing.T, env string) string {
 	return v
 }
 
+// isKitchenSink reports whether the image is the kitchen sink running as root. The provider build
+// environment is built from the same stage, so it counts as the kitchen sink too.
 func isKitchenSink(t *testing.T) bool {
 	imageVariant := mustEnv(t, "IMAGE_VARIANT")
-	return imageVariant == "pulumi"
+	return imageVariant == "pulumi" || isBuildEnvironment(t)
+}
+
+// hasKitchenSinkTools reports whether the image ships the kitchen sink's tools, e.g. helm,
+// kubectl, docker and the cloud CLIs. Unlike isKitchenSink, this includes the nonroot image.
+func hasKitchenSinkTools(t *testing.T) bool {
+	return isKitchenSink(t) || isNonRoot(t)
+}
+
+func isBuildEnvironment(t *testing.T) bool {
+	imageVariant := mustEnv(t, "IMAGE_VARIANT")
+	return imageVariant == "pulumi-provider-build-environment"
 }
 
 func hasPython(t *testing.T) bool {
@@ -669,6 +688,42 @@ func hasNodejs(t *testing.T) bool {
 	return strings.HasSuffix(imageVariant, "nodejs") || isKitchenSink(t)
 }
 
+func hasGo(t *testing.T) bool {
+	imageVariant := mustEnv(t, "IMAGE_VARIANT")
+	return strings.HasSuffix(imageVariant, "-go") || isKitchenSink(t)
+}
+
+func hasDotnet(t *testing.T) bool {
+	imageVariant := mustEnv(t, "IMAGE_VARIANT")
+	return strings.HasSuffix(imageVariant, "dotnet") || isKitchenSink(t)
+}
+
+func hasJava(t *testing.T) bool {
+	imageVariant := mustEnv(t, "IMAGE_VARIANT")
+	return strings.HasSuffix(imageVariant, "java") || isKitchenSink(t)
+}
+
+// hasSDK reports whether the image ships the runtime for the given SDK, using the same SDK
+// names as SDKS_TO_TEST. YAML is supported by every image.
+func hasSDK(t *testing.T, sdk string) bool {
+	switch sdk {
+	case "typescript", "nodejs":
+		return hasNodejs(t)
+	case "python":
+		return hasPython(t)
+	case "go":
+		return hasGo(t)
+	case "csharp", "dotnet":
+		return hasDotnet(t)
+	case "java":
+		return hasJava(t)
+	case "yaml":
+		return true
+	}
+	t.Fatalf("unknown sdk %q", sdk)
+	return false
+}
+
 func isDebian(t *testing.T) bool {
 	imageVariant := mustEnv(t, "IMAGE_VARIANT")
 	return strings.HasPrefix(imageVariant, "pulumi-debian")
@@ -684,6 +739,39 @@ func isNonRoot(t *testing.T) bool {
 	return strings.HasSuffix(imageVariant, "-nonroot")
 }
 
+// imageUser is the user an image runs as.
+type imageUser struct {
+	Name string
+	UID  int
+	GID  int
+	Home string
+}
+
+// expectedUser returns the user the image is expected to run as. The nonroot image can be built
+// with `--build-arg UID=... --build-arg GID=...` to match the owner of a bind mount on the host,
+// so IMAGE_USER, IMAGE_UID, IMAGE_GID and IMAGE_HOME override the defaults.
+func expectedUser(t *testing.T) imageUser {
+	t.Helper()
+	user := imageUser{Name: "root", UID: 0, GID: 0, Home: "/root"}
+	if isNonRoot(t) {
+		user = imageUser{Name: "pulumi", UID: 1000, GID: 1000, Home: "/home/pulumi"}
+	}
+	if name := os.Getenv("IMAGE_USER"); name != "" {
+		user.Name = name
+	}
+	for env, id := range map[string]*int{"IMAGE_UID": &user.UID, "IMAGE_GID": &user.GID} {
+		if v := os.Getenv(env); v != "" {
+			n, err := strconv.Atoi(v)
+			require.NoError(t, err, "invalid %s", env)
+			*id = n
+		}
+	}
+	if home := os.Getenv("IMAGE_HOME"); home != "" {
+		user.Home = home
+	}
+	return user
+}
+
 func RandomStackName(t *testing.T) string {
 	t.Helper()
 	b := make([]byte, 4)
@@ -692,6 +780,17 @@ func RandomStackName(t *testing.T) string {
 	return "test" + hex.EncodeToString(b)
 }
 
+// isProgramDir reports whether the embedded directory at path is a Pulumi program, as opposed to
+// a directory that groups fixtures for a specific test.
+func isProgramDir(t *testing.T, path string) bool {
+	_, err := fs.Stat(testdata, filepath.Join(path, "Pulumi.yaml"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return false
+	}
+	require.NoError(t, err)
+	return true
+}
+
 func copyTestData(t *testing.T, path string) {
 	require.NoError(t, os.MkdirAll(path, os.ModePerm))
 	files, err := testdata.ReadDir(path)
@@ -703,3 +802,34 @@ func copyTestData(t *testing.T, path string) {
 		require.NoError(t, os.WriteFile(p, fileContent, os.ModePerm), "writefile")
 	}
 }
+
+// copyTestDataDir recursively copies the embedded directory at path to dst on disk.
+//
+// Go refuses to embed directories that contain a go.mod file, since they are separate modules.
+// Fixtures store these files as go.mod.txt and go.sum.txt instead, and they are renamed here.
+func copyTestDataDir(t *testing.T, path, dst string) {
+	t.Helper()
+	err := fs.WalkDir(testdata, path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(path, p)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, os.ModePerm)
+		}
+		switch d.Name() {
+		case "go.mod.txt", "go.sum.txt":
+			target = strings.TrimSuffix(target, ".txt")
+		}
+		content, err := testdata.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(target, content, os.ModePerm)
+	})
+	require.NoError(t, err, "copying %s to %s", path, dst)
+}
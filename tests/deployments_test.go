@@ -0,0 +1,109 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package containers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	ptesting "github.com/pulumi/pulumi/sdk/v3/go/common/testing"
+	"github.com/stretchr/testify/require"
+)
+
+// executorScript stands in for the executor binary that Deployments downloads into the image.
+const executorScript = `#!/bin/sh
+echo "pulumi-deploy-executor v0.0.0-test"
+`
+
+// TestDeploymentsExecutor reproduces the sequence of steps Pulumi Deployments runs when it uses
+// one of our images as a custom executor image:
+//
+//  1. Download the executor binary with curl.
+//  2. Clone the project's source with git.
+//  3. Run the user's pre-run commands.
+//  4. Run `pulumi install` and the requested operation.
+//
+// Every step after the download runs through bash with BASH_ENV set, like Deployments does. Each
+// piece of the flow is served locally, so this runs without network access or credentials.
+//
+// NOTE: This test is intended to be run inside the container.
+func TestDeploymentsExecutor(t *testing.T) {
+	t.Parallel()
+
+	e := ptesting.NewEnvironment(t)
+	defer e.DeleteIfNotFailed()
+	e.SetBackend(e.LocalURL())
+	e.SetEnvVars(bashEnv)
+
+	// 1. Download the executor.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/pulumi-deploy-executor" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		_, _ = w.Write([]byte(executorScript))
+	}))
+	defer server.Close()
+
+	executor := filepath.Join(e.RootPath, "pulumi-deploy-executor")
+	e.RunCommand("curl", "--fail", "--silent", "--show-error", "--location",
+		"--output", executor, server.URL+"/pulumi-deploy-executor")
+	require.NoError(t, os.Chmod(executor, 0o755))
+	stdout, _ := e.RunCommand(executor)
+	require.Equal(t, "pulumi-deploy-executor v0.0.0-test", strings.TrimSpace(stdout))
+
+	// 2. Clone the source.
+	source := filepath.Join(e.RootPath, "source")
+	copyTestDataDir(t, filepath.Join("testdata", "deployments"), source)
+	e.CWD = source
+	e.RunCommand("git", "init", "--initial-branch=main")
+	e.RunCommand("git", "add", ".")
+	e.RunCommand("git", "-c", "user.name=Pulumi", "-c", "user.email=bot@pulumi.com",
+		"commit", "--message", "Initial commit")
+
+	workdir := filepath.Join(e.RootPath, "workdir")
+	e.CWD = e.RootPath
+	runDeploymentStep(e, "git clone --depth=1 --branch=main file://"+source+" "+workdir)
+	e.CWD = workdir
+	require.FileExists(t, filepath.Join(workdir, "Pulumi.yaml"))
+
+	// 3. Run the pre-run commands.
+	for _, command := range []string{
+		"pulumi version",
+		"git rev-parse HEAD",
+		"curl --version",
+	} {
+		runDeploymentStep(e, command)
+	}
+
+	// 4. Install and preview.
+	runDeploymentStep(e, "pulumi stack select --create dev")
+	runDeploymentStep(e, "pulumi install")
+	stdout = runDeploymentStep(e, "pulumi preview --non-interactive")
+	require.Contains(t, stdout, "hello from deployments")
+}
+
+// runDeploymentStep runs command the way the Deployments executor runs each step, and returns
+// its stdout.
+func runDeploymentStep(e *ptesting.Environment, command string) string {
+	e.Helper()
+	stdout, _ := e.RunCommand("/bin/bash", "-c", command)
+	return stdout
+}
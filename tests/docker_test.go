@@ -0,0 +1,156 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package containers
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestDockerCLI runs the kitchen sink's docker CLI and its buildx plugin against a fake Docker
+// Engine API on a unix socket, since there is no daemon inside the container.
+//
+// NOTE: This test is intended to be run inside the container.
+func TestDockerCLI(t *testing.T) {
+	if !hasKitchenSinkTools(t) {
+		t.Skip("The docker CLI is only installed in the kitchen sink images")
+	}
+	t.Parallel()
+
+	engine := newFakeDockerEngine(t)
+
+	t.Run("version", func(t *testing.T) {
+		t.Parallel()
+
+		// The subtests run in parallel, so this one gets its own engine to only see its own
+		// requests.
+		engine := newFakeDockerEngine(t)
+		out := runDocker(t, dockerEnv(t, engine.Host()), "version", "--format", "{{json .}}")
+		var version struct {
+			Client struct {
+				Version    string
+				APIVersion string `json:"ApiVersion"`
+			}
+			Server struct {
+				Version string
+			}
+		}
+		require.NoError(t, json.Unmarshal([]byte(out), &version), out)
+		require.NotEmpty(t, version.Client.Version)
+		require.NotEmpty(t, version.Client.APIVersion)
+		require.Equal(t, fakeDockerVersion, version.Server.Version)
+
+		var paths []string
+		for _, req := range engine.Requests() {
+			require.True(t, strings.HasPrefix(req.UserAgent, "Docker-Client/"), "unexpected user agent %q", req.UserAgent)
+			paths = append(paths, req.Path)
+		}
+		require.Contains(t, paths, "/_ping")
+		require.Contains(t, paths, "/version")
+	})
+
+	t.Run("info", func(t *testing.T) {
+		t.Parallel()
+
+		out := runDocker(t, dockerEnv(t, engine.Host()), "info", "--format", "{{json .}}")
+		var info struct {
+			ServerVersion string
+			ServerErrors  []string
+			ClientInfo    struct {
+				Plugins []struct {
+					Name    string
+					Version string
+					Path    string
+					Err     any
+				}
+			}
+		}
+		require.NoError(t, json.Unmarshal([]byte(out), &info), out)
+		require.Empty(t, info.ServerErrors)
+		require.Equal(t, fakeDockerVersion, info.ServerVersion)
+
+		plugins := map[string]string{}
+		for _, plugin := range info.ClientInfo.Plugins {
+			require.Nil(t, plugin.Err, "plugin %s at %s is broken", plugin.Name, plugin.Path)
+			plugins[plugin.Name] = plugin.Path
+		}
+		require.Equal(t, "/usr/libexec/docker/cli-plugins/docker-buildx", plugins["buildx"])
+	})
+
+	t.Run("buildx version", func(t *testing.T) {
+		t.Parallel()
+
+		out := runDocker(t, dockerEnv(t, engine.Host()), "buildx", "version")
+		require.True(t, strings.HasPrefix(out, "github.com/docker/buildx v"), "unexpected output %q", out)
+	})
+
+	t.Run("context", func(t *testing.T) {
+		t.Parallel()
+
+		// Without DOCKER_HOST the CLI connects through the current context.
+		env := dockerEnv(t, "")
+		runDocker(t, env, "context", "create", "fake", "--docker", "host="+engine.Host())
+		out := runDocker(t, env, "context", "inspect", "fake", "--format", "{{.Endpoints.docker.Host}}")
+		require.Equal(t, engine.Host(), strings.TrimSpace(out))
+
+		runDocker(t, env, "context", "use", "fake")
+		out = runDocker(t, env, "context", "show")
+		require.Equal(t, "fake", strings.TrimSpace(out))
+		out = runDocker(t, env, "version", "--format", "{{.Server.Version}}")
+		require.Equal(t, fakeDockerVersion, strings.TrimSpace(out))
+
+		// DOCKER_HOST takes precedence over the current context.
+		out = runDocker(t, append(env, "DOCKER_HOST="+engine.Host()), "context", "show")
+		require.Equal(t, "default", strings.TrimSpace(out))
+	})
+}
+
+// dockerEnv returns an environment for the docker CLI with its own configuration directory. If
+// host is not empty, it is used as DOCKER_HOST.
+func dockerEnv(t *testing.T, host string) []string {
+	t.Helper()
+	var env []string
+	for _, kv := range os.Environ() {
+		if !strings.HasPrefix(kv, "DOCKER_") {
+			env = append(env, kv)
+		}
+	}
+	env = append(env, "DOCKER_CONFIG="+t.TempDir())
+	if host != "" {
+		env = append(env, "DOCKER_HOST="+host)
+	}
+	return env
+}
+
+// runDocker runs the docker CLI with env as its environment and returns its stdout.
+func runDocker(t *testing.T, env []string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("docker", args...)
+	cmd.Env = env
+	t.Logf("Running %q", cmd.String())
+	out, err := cmd.Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		t.Logf("stderr: %s", exitErr.Stderr)
+	}
+	require.NoError(t, err)
+	return string(out)
+}
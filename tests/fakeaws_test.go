@@ -0,0 +1,474 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package containers
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeAccountID is the AWS account that every caller of fakeAWS belongs to.
+const fakeAccountID = "123456789012"
+
+// fakeAWS is a stand-in for the AWS APIs used by the tests: sts:GetCallerIdentity and the S3
+// bucket and object operations the AWS CLI and the DIY backend use, with path-style addressing.
+// It does not verify signatures, but it does require requests to be signed, and it derives the
+// caller's identity from the access key in the signature.
+type fakeAWS struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	calls   []fakeAWSCall
+	buckets map[string]map[string]fakeS3Object // bucket name to objects by key
+	block   *fakeS3Block
+}
+
+// fakeS3Block holds PutObject requests, see fakeAWS.BlockPutObject.
+type fakeS3Block struct {
+	bucket, prefix string
+	held, released chan struct{}
+	heldOnce       sync.Once
+}
+
+// fakeS3Object is an object stored in fakeAWS.
+type fakeS3Object struct {
+	Data         []byte
+	ContentType  string
+	LastModified time.Time
+}
+
+// ETag returns the object's entity tag, which S3 computes as the MD5 of the content for objects
+// that were not uploaded in parts.
+func (o fakeS3Object) ETag() string {
+	sum := md5.Sum(o.Data)
+	return `"` + hex.EncodeToString(sum[:]) + `"`
+}
+
+// fakeAWSCall records a request made to fakeAWS.
+type fakeAWSCall struct {
+	Service     string
+	Action      string
+	AccessKeyID string
+	Region      string
+}
+
+func newFakeAWS(t *testing.T) *fakeAWS {
+	aws := &fakeAWS{buckets: map[string]map[string]fakeS3Object{}}
+	aws.Server = httptest.NewServer(http.HandlerFunc(aws.serveHTTP))
+	t.Cleanup(aws.Close)
+	return aws
+}
+
+// Calls returns the calls the server has received so far.
+func (aws *fakeAWS) Calls() []fakeAWSCall {
+	aws.mu.Lock()
+	defer aws.mu.Unlock()
+	return append([]fakeAWSCall(nil), aws.calls...)
+}
+
+// fakeAWSArn returns the ARN that fakeAWS reports for the given access key.
+func fakeAWSArn(accessKeyID string) string {
+	return fmt.Sprintf("arn:aws:iam::%s:user/%s", fakeAccountID, accessKeyID)
+}
+
+// awsCredentialPattern extracts the access key, region and service from the credential scope of a
+// SigV4 signature, either from the Authorization header or from a presigned URL.
+var awsCredentialPattern = regexp.MustCompile(`Credential=([^/]+)/\d{8}/([^/]+)/([^/]+)/aws4_request`)
+
+func (aws *fakeAWS) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	credential := r.Header.Get("Authorization")
+	if credential == "" {
+		credential = "Credential=" + r.URL.Query().Get("X-Amz-Credential")
+	}
+	match := awsCredentialPattern.FindStringSubmatch(credential)
+	if match == nil {
+		writeAWSError(w, http.StatusForbidden, "MissingAuthenticationToken", "Request is missing a SigV4 signature")
+		return
+	}
+	call := fakeAWSCall{AccessKeyID: match[1], Region: match[2], Service: match[3]}
+
+	switch call.Service {
+	case "sts":
+		if err := r.ParseForm(); err != nil {
+			writeAWSError(w, http.StatusBadRequest, "InvalidRequest", err.Error())
+			return
+		}
+		call.Action = r.Form.Get("Action")
+		aws.record(call)
+		aws.serveSTS(w, call)
+	case "s3":
+		aws.serveS3(w, r, call)
+	default:
+		aws.record(call)
+		writeAWSError(w, http.StatusBadRequest, "UnknownService", "Unsupported service "+call.Service)
+	}
+}
+
+func (aws *fakeAWS) record(call fakeAWSCall) {
+	aws.mu.Lock()
+	defer aws.mu.Unlock()
+	aws.calls = append(aws.calls, call)
+}
+
+func (aws *fakeAWS) serveSTS(w http.ResponseWriter, call fakeAWSCall) {
+	if call.Action != "GetCallerIdentity" {
+		writeAWSError(w, http.StatusBadRequest, "InvalidAction", "Unsupported action "+call.Action)
+		return
+	}
+	type result struct {
+		Arn     string `xml:"Arn"`
+		UserID  string `xml:"UserId"`
+		Account string `xml:"Account"`
+	}
+	writeAWSXML(w, http.StatusOK, struct {
+		XMLName   xml.Name `xml:"https://sts.amazonaws.com/doc/2011-06-15/ GetCallerIdentityResponse"`
+		Result    result   `xml:"GetCallerIdentityResult"`
+		RequestID string   `xml:"ResponseMetadata>RequestId"`
+	}{
+		Result: result{
+			Arn:     fakeAWSArn(call.AccessKeyID),
+			UserID:  call.AccessKeyID,
+			Account: fakeAccountID,
+		},
+		RequestID: "00000000-0000-0000-0000-000000000000",
+	})
+}
+
+// Object returns the content of an S3 object, and whether it exists.
+func (aws *fakeAWS) Object(bucket, key string) ([]byte, bool) {
+	aws.mu.Lock()
+	defer aws.mu.Unlock()
+	obj, ok := aws.buckets[bucket][key]
+	return obj.Data, ok
+}
+
+// Keys returns the keys of the objects in bucket that start with prefix, sorted.
+func (aws *fakeAWS) Keys(bucket, prefix string) []string {
+	aws.mu.Lock()
+	defer aws.mu.Unlock()
+	keys := []string{}
+	for key := range aws.buckets[bucket] {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// CreateBucket creates an empty bucket.
+func (aws *fakeAWS) CreateBucket(bucket string) {
+	aws.mu.Lock()
+	defer aws.mu.Unlock()
+	aws.buckets[bucket] = map[string]fakeS3Object{}
+}
+
+// PutObject stores an object, as if it had been uploaded by another client.
+func (aws *fakeAWS) PutObject(bucket, key string, data []byte) {
+	aws.mu.Lock()
+	defer aws.mu.Unlock()
+	aws.buckets[bucket][key] = fakeS3Object{Data: data, LastModified: time.Now().UTC()}
+}
+
+// BlockPutObject holds PutObject requests for keys in bucket that start with prefix until release
+// is called, which lets a test pause a client in the middle of an operation. held is closed when
+// the first such request arrives. Other requests are served while a request is held.
+func (aws *fakeAWS) BlockPutObject(bucket, prefix string) (held <-chan struct{}, release func()) {
+	block := &fakeS3Block{
+		bucket:   bucket,
+		prefix:   prefix,
+		held:     make(chan struct{}),
+		released: make(chan struct{}),
+	}
+	aws.mu.Lock()
+	aws.block = block
+	aws.mu.Unlock()
+
+	var releaseOnce sync.Once
+	return block.held, func() {
+		releaseOnce.Do(func() {
+			aws.mu.Lock()
+			aws.block = nil
+			aws.mu.Unlock()
+			close(block.released)
+		})
+	}
+}
+
+// s3Namespace is the XML namespace of S3 responses.
+const s3Namespace = "http://s3.amazonaws.com/doc/2006-03-01/"
+
+// serveS3 serves S3 requests. Requests are expected to use path-style addressing, i.e.
+// /<bucket>/<key>.
+func (aws *fakeAWS) serveS3(w http.ResponseWriter, r *http.Request, call fakeAWSCall) {
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	query := r.URL.Query()
+
+	aws.mu.Lock()
+	block := aws.block
+	aws.mu.Unlock()
+	if block != nil && r.Method == http.MethodPut && bucket == block.bucket && key != "" &&
+		strings.HasPrefix(key, block.prefix) {
+		block.heldOnce.Do(func() { close(block.held) })
+		<-block.released
+	}
+
+	aws.mu.Lock()
+	defer aws.mu.Unlock()
+
+	objects, bucketExists := aws.buckets[bucket]
+	switch {
+	case bucket == "" && r.Method == http.MethodGet:
+		call.Action = "ListBuckets"
+		aws.serveListBuckets(w)
+	case key == "" && r.Method == http.MethodPut:
+		call.Action = "CreateBucket"
+		if bucketExists {
+			writeS3Error(w, http.StatusConflict, "BucketAlreadyOwnedByYou", "bucket "+bucket+" already exists")
+			break
+		}
+		aws.buckets[bucket] = map[string]fakeS3Object{}
+		w.Header().Set("Location", "/"+bucket)
+		w.WriteHeader(http.StatusOK)
+	case !bucketExists:
+		call.Action = r.Method + "Object"
+		writeS3Error(w, http.StatusNotFound, "NoSuchBucket", "bucket "+bucket+" does not exist")
+	case key == "" && r.Method == http.MethodHead:
+		call.Action = "HeadBucket"
+		w.WriteHeader(http.StatusOK)
+	case key == "" && r.Method == http.MethodGet:
+		call.Action = "ListObjectsV2"
+		serveListObjects(w, bucket, objects, query.Get("prefix"), query.Get("delimiter"))
+	case key == "":
+		call.Action = r.Method + "Bucket"
+		writeS3Error(w, http.StatusNotImplemented, "NotImplemented", r.Method+" is not supported on buckets")
+	case r.Method == http.MethodPut && r.Header.Get("X-Amz-Copy-Source") != "":
+		call.Action = "CopyObject"
+		source, err := url.PathUnescape(strings.TrimPrefix(r.Header.Get("X-Amz-Copy-Source"), "/"))
+		if err != nil {
+			writeS3Error(w, http.StatusBadRequest, "InvalidArgument", err.Error())
+			break
+		}
+		sourceBucket, sourceKey, _ := strings.Cut(source, "/")
+		obj, ok := aws.buckets[sourceBucket][sourceKey]
+		if !ok {
+			writeS3Error(w, http.StatusNotFound, "NoSuchKey", "key "+source+" does not exist")
+			break
+		}
+		obj.LastModified = time.Now().UTC()
+		objects[key] = obj
+		writeAWSXML(w, http.StatusOK, struct {
+			XMLName      xml.Name `xml:"CopyObjectResult"`
+			ETag         string   `xml:"ETag"`
+			LastModified string   `xml:"LastModified"`
+		}{ETag: obj.ETag(), LastModified: obj.LastModified.Format(time.RFC3339)})
+	case r.Method == http.MethodPut:
+		call.Action = "PutObject"
+		data, err := readS3Payload(r)
+		if err != nil {
+			writeS3Error(w, http.StatusBadRequest, "InvalidRequest", err.Error())
+			break
+		}
+		obj := fakeS3Object{Data: data, ContentType: r.Header.Get("Content-Type"), LastModified: time.Now().UTC()}
+		objects[key] = obj
+		w.Header().Set("ETag", obj.ETag())
+		w.WriteHeader(http.StatusOK)
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		call.Action = "GetObject"
+		if r.Method == http.MethodHead {
+			call.Action = "HeadObject"
+		}
+		obj, ok := objects[key]
+		if !ok {
+			writeS3Error(w, http.StatusNotFound, "NoSuchKey", "key "+key+" does not exist")
+			break
+		}
+		contentType := obj.ContentType
+		if contentType == "" {
+			contentType = "binary/octet-stream"
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Length", strconv.Itoa(len(obj.Data)))
+		w.Header().Set("ETag", obj.ETag())
+		w.Header().Set("Last-Modified", obj.LastModified.Format(http.TimeFormat))
+		w.WriteHeader(http.StatusOK)
+		if r.Method == http.MethodGet {
+			_, _ = w.Write(obj.Data)
+		}
+	case r.Method == http.MethodDelete:
+		call.Action = "DeleteObject"
+		delete(objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		call.Action = r.Method + "Object"
+		writeS3Error(w, http.StatusNotImplemented, "NotImplemented", r.Method+" is not supported on objects")
+	}
+
+	aws.calls = append(aws.calls, call)
+}
+
+func (aws *fakeAWS) serveListBuckets(w http.ResponseWriter) {
+	type bucket struct {
+		Name         string `xml:"Name"`
+		CreationDate string `xml:"CreationDate"`
+	}
+	var buckets []bucket
+	for name := range aws.buckets {
+		buckets = append(buckets, bucket{Name: name, CreationDate: "2026-01-01T00:00:00.000Z"})
+	}
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i].Name < buckets[j].Name })
+	writeAWSXML(w, http.StatusOK, struct {
+		XMLName xml.Name `xml:"ListAllMyBucketsResult"`
+		Xmlns   string   `xml:"xmlns,attr"`
+		OwnerID string   `xml:"Owner>ID"`
+		Buckets []bucket `xml:"Buckets>Bucket"`
+	}{Xmlns: s3Namespace, OwnerID: fakeAccountID, Buckets: buckets})
+}
+
+// serveListObjects serves ListObjectsV2. Results are never truncated.
+func serveListObjects(w http.ResponseWriter, bucket string, objects map[string]fakeS3Object, prefix, delimiter string) {
+	type content struct {
+		Key          string `xml:"Key"`
+		LastModified string `xml:"LastModified"`
+		ETag         string `xml:"ETag"`
+		Size         int    `xml:"Size"`
+		StorageClass string `xml:"StorageClass"`
+	}
+	type commonPrefix struct {
+		Prefix string `xml:"Prefix"`
+	}
+	var contents []content
+	var prefixes []commonPrefix
+	seen := map[string]bool{}
+	keys := make([]string, 0, len(objects))
+	for key := range objects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		if delimiter != "" {
+			if i := strings.Index(key[len(prefix):], delimiter); i >= 0 {
+				p := key[:len(prefix)+i+len(delimiter)]
+				if !seen[p] {
+					seen[p] = true
+					prefixes = append(prefixes, commonPrefix{Prefix: p})
+				}
+				continue
+			}
+		}
+		obj := objects[key]
+		contents = append(contents, content{
+			Key:          key,
+			LastModified: obj.LastModified.Format("2006-01-02T15:04:05.000Z"),
+			ETag:         obj.ETag(),
+			Size:         len(obj.Data),
+			StorageClass: "STANDARD",
+		})
+	}
+	writeAWSXML(w, http.StatusOK, struct {
+		XMLName        xml.Name       `xml:"ListBucketResult"`
+		Xmlns          string         `xml:"xmlns,attr"`
+		Name           string         `xml:"Name"`
+		Prefix         string         `xml:"Prefix"`
+		Delimiter      string         `xml:"Delimiter,omitempty"`
+		KeyCount       int            `xml:"KeyCount"`
+		MaxKeys        int            `xml:"MaxKeys"`
+		IsTruncated    bool           `xml:"IsTruncated"`
+		Contents       []content      `xml:"Contents"`
+		CommonPrefixes []commonPrefix `xml:"CommonPrefixes"`
+	}{
+		Xmlns:          s3Namespace,
+		Name:           bucket,
+		Prefix:         prefix,
+		Delimiter:      delimiter,
+		KeyCount:       len(contents) + len(prefixes),
+		MaxKeys:        1000,
+		Contents:       contents,
+		CommonPrefixes: prefixes,
+	})
+}
+
+// readS3Payload reads the body of an upload. Recent SDKs send uploads with aws-chunked content
+// encoding so that they can add a checksum trailer; the chunks are decoded and the trailer is
+// ignored.
+func readS3Payload(r *http.Request) ([]byte, error) {
+	if !strings.Contains(r.Header.Get("Content-Encoding"), "aws-chunked") &&
+		!strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+		return io.ReadAll(r.Body)
+	}
+	var data bytes.Buffer
+	reader := bufio.NewReader(r.Body)
+	for {
+		header, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, fmt.Errorf("reading chunk header: %w", err)
+		}
+		sizeHex, _, _ := strings.Cut(strings.TrimSpace(header), ";")
+		size, err := strconv.ParseInt(sizeHex, 16, 64)
+		if err != nil {
+			return nil, fmt.Errorf("parsing chunk size %q: %w", header, err)
+		}
+		if size == 0 {
+			return data.Bytes(), nil
+		}
+		if _, err := io.CopyN(&data, reader, size); err != nil {
+			return nil, fmt.Errorf("reading chunk: %w", err)
+		}
+		if _, err := reader.Discard(2); err != nil {
+			return nil, fmt.Errorf("reading chunk terminator: %w", err)
+		}
+	}
+}
+
+func writeAWSXML(w http.ResponseWriter, code int, body any) {
+	w.Header().Set("Content-Type", "text/xml")
+	w.WriteHeader(code)
+	_, _ = w.Write([]byte(xml.Header))
+	_ = xml.NewEncoder(w).Encode(body)
+}
+
+func writeAWSError(w http.ResponseWriter, code int, errorCode, message string) {
+	writeAWSXML(w, code, struct {
+		XMLName xml.Name `xml:"ErrorResponse"`
+		Code    string   `xml:"Error>Code"`
+		Message string   `xml:"Error>Message"`
+	}{Code: errorCode, Message: message})
+}
+
+func writeS3Error(w http.ResponseWriter, code int, errorCode, message string) {
+	writeAWSXML(w, code, struct {
+		XMLName xml.Name `xml:"Error"`
+		Code    string   `xml:"Code"`
+		Message string   `xml:"Message"`
+	}{Code: errorCode, Message: message})
+}
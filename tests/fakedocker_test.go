@@ -0,0 +1,168 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package containers
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// fakeDockerVersion is the engine version reported by fakeDockerEngine.
+	fakeDockerVersion = "0.0.0-fake"
+	// fakeDockerAPIVersion is the newest API version fakeDockerEngine claims to support. Clients
+	// negotiate down to the newest version they support themselves.
+	fakeDockerAPIVersion = "1.99"
+)
+
+// fakeDockerEngine serves the Docker Engine API endpoints that the docker CLI needs for version
+// negotiation, `docker version` and `docker info`, on a unix socket.
+type fakeDockerEngine struct {
+	// Socket is the path of the unix socket the engine listens on.
+	Socket string
+
+	mu       sync.Mutex
+	requests []fakeDockerRequest
+}
+
+// fakeDockerRequest records a request made to fakeDockerEngine.
+type fakeDockerRequest struct {
+	Method    string
+	Path      string
+	UserAgent string
+}
+
+// dockerAPIVersionPrefix matches the version prefix of versioned API paths, e.g. /v1.47.
+var dockerAPIVersionPrefix = regexp.MustCompile(`^/v[0-9]+\.[0-9]+`)
+
+func newFakeDockerEngine(t *testing.T) *fakeDockerEngine {
+	// Unix socket paths are limited to about 100 bytes, which t.TempDir() can exceed.
+	dir, err := os.MkdirTemp("", "docker")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	engine := &fakeDockerEngine{Socket: filepath.Join(dir, "docker.sock")}
+	l, err := net.Listen("unix", engine.Socket)
+	require.NoError(t, err)
+	server := &http.Server{Handler: http.HandlerFunc(engine.serveHTTP)}
+	go func() {
+		if err := server.Serve(l); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			t.Logf("fake docker engine: %v", err)
+		}
+	}()
+	t.Cleanup(func() { server.Close() })
+	return engine
+}
+
+// Host returns the engine's address in the form DOCKER_HOST expects.
+func (engine *fakeDockerEngine) Host() string {
+	return "unix://" + engine.Socket
+}
+
+// Requests returns the requests the engine has received so far.
+func (engine *fakeDockerEngine) Requests() []fakeDockerRequest {
+	engine.mu.Lock()
+	defer engine.mu.Unlock()
+	return append([]fakeDockerRequest(nil), engine.requests...)
+}
+
+func (engine *fakeDockerEngine) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	path := dockerAPIVersionPrefix.ReplaceAllString(r.URL.Path, "")
+	engine.mu.Lock()
+	engine.requests = append(engine.requests, fakeDockerRequest{
+		Method:    r.Method,
+		Path:      path,
+		UserAgent: r.UserAgent(),
+	})
+	engine.mu.Unlock()
+
+	w.Header().Set("Api-Version", fakeDockerAPIVersion)
+	w.Header().Set("Docker-Experimental", "false")
+	w.Header().Set("Ostype", "linux")
+	w.Header().Set("Server", "Docker/"+fakeDockerVersion+" (linux)")
+
+	switch path {
+	case "/_ping":
+		w.Header().Set("Builder-Version", "2")
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		if r.Method == http.MethodGet {
+			_, _ = w.Write([]byte("OK"))
+		}
+	case "/version":
+		writeDockerJSON(w, http.StatusOK, map[string]any{
+			"Platform": map[string]any{"Name": "Fake Docker Engine"},
+			"Components": []map[string]any{{
+				"Name":    "Engine",
+				"Version": fakeDockerVersion,
+				"Details": map[string]any{
+					"ApiVersion":    fakeDockerAPIVersion,
+					"MinAPIVersion": "1.24",
+					"Os":            "linux",
+					"Arch":          runtime.GOARCH,
+				},
+			}},
+			"Version":       fakeDockerVersion,
+			"ApiVersion":    fakeDockerAPIVersion,
+			"MinAPIVersion": "1.24",
+			"GitCommit":     "0000000",
+			"GoVersion":     runtime.Version(),
+			"Os":            "linux",
+			"Arch":          runtime.GOARCH,
+			"KernelVersion": "0.0.0",
+			"BuildTime":     "2026-01-01T00:00:00.000000000+00:00",
+		})
+	case "/info":
+		writeDockerJSON(w, http.StatusOK, map[string]any{
+			"ID":                 "FAKE",
+			"Name":               "fake-docker-engine",
+			"ServerVersion":      fakeDockerVersion,
+			"OperatingSystem":    "Fake Docker Engine",
+			"OSType":             "linux",
+			"Architecture":       runtime.GOARCH,
+			"KernelVersion":      "0.0.0",
+			"NCPU":               1,
+			"MemTotal":           1 << 30,
+			"Driver":             "overlay2",
+			"DockerRootDir":      "/var/lib/docker",
+			"IndexServerAddress": "https://index.docker.io/v1/",
+			"Containers":         0,
+			"Images":             0,
+			"SecurityOptions":    []string{},
+			"Plugins":            map[string]any{"Volume": []string{"local"}, "Network": []string{"bridge"}},
+			"Warnings":           []string{},
+		})
+	default:
+		writeDockerJSON(w, http.StatusNotFound, map[string]any{
+			"message": "page not found: " + r.Method + " " + r.URL.Path,
+		})
+	}
+}
+
+func writeDockerJSON(w http.ResponseWriter, code int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(body)
+}
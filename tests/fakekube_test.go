@@ -0,0 +1,305 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package containers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeKubeAPI is a minimal Kubernetes API server. It serves discovery for the core v1 group and
+// keeps namespaced ConfigMaps and Secrets in memory, which is enough for kubectl and helm to
+// talk to it as if it were a cluster.
+type fakeKubeAPI struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	objects  map[string]map[string]any // keyed by "<resource>/<namespace>/<name>"
+	requests []fakeKubeRequest
+}
+
+// fakeKubeRequest records a request made to the fake API server.
+type fakeKubeRequest struct {
+	Method        string
+	Path          string
+	Query         string
+	Authorization string
+}
+
+// fakeKubeKinds maps the resources served by fakeKubeAPI to their kinds.
+var fakeKubeKinds = map[string]string{
+	"configmaps": "ConfigMap",
+	"secrets":    "Secret",
+}
+
+func newFakeKubeAPI(t *testing.T) *fakeKubeAPI {
+	api := &fakeKubeAPI{objects: map[string]map[string]any{}}
+	api.Server = httptest.NewTLSServer(http.HandlerFunc(api.serveHTTP))
+	t.Cleanup(api.Close)
+	return api
+}
+
+// Requests returns the requests the server has received so far.
+func (api *fakeKubeAPI) Requests() []fakeKubeRequest {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	return append([]fakeKubeRequest(nil), api.requests...)
+}
+
+// Put stores an object as if it had been created in the cluster.
+func (api *fakeKubeAPI) Put(resource, namespace, name string, data map[string]any) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	api.objects[resource+"/"+namespace+"/"+name] = api.newObject(resource, namespace, name, data)
+}
+
+// Get returns a stored object, or nil if it does not exist.
+func (api *fakeKubeAPI) Get(resource, namespace, name string) map[string]any {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	return api.objects[resource+"/"+namespace+"/"+name]
+}
+
+func (api *fakeKubeAPI) newObject(resource, namespace, name string, data map[string]any) map[string]any {
+	obj := map[string]any{
+		"apiVersion": "v1",
+		"kind":       fakeKubeKinds[resource],
+		"metadata": map[string]any{
+			"name":              name,
+			"namespace":         namespace,
+			"uid":               fmt.Sprintf("%s-%s-%s", resource, namespace, name),
+			"resourceVersion":   "1",
+			"creationTimestamp": time.Now().UTC().Format(time.RFC3339),
+		},
+	}
+	if data != nil {
+		obj["data"] = data
+	}
+	return obj
+}
+
+func (api *fakeKubeAPI) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	api.mu.Lock()
+	api.requests = append(api.requests, fakeKubeRequest{
+		Method:        r.Method,
+		Path:          r.URL.Path,
+		Query:         r.URL.RawQuery,
+		Authorization: r.Header.Get("Authorization"),
+	})
+	api.mu.Unlock()
+
+	switch path := strings.Trim(r.URL.Path, "/"); {
+	case path == "version":
+		writeKubeJSON(w, http.StatusOK, map[string]any{
+			"major":      "1",
+			"minor":      "31",
+			"gitVersion": "v1.31.0",
+			"platform":   "linux/amd64",
+		})
+	case path == "api":
+		writeKubeJSON(w, http.StatusOK, map[string]any{
+			"kind":     "APIVersions",
+			"versions": []string{"v1"},
+			"serverAddressByClientCIDRs": []map[string]string{
+				{"clientCIDR": "0.0.0.0/0", "serverAddress": r.Host},
+			},
+		})
+	case path == "apis":
+		writeKubeJSON(w, http.StatusOK, map[string]any{
+			"kind":       "APIGroupList",
+			"apiVersion": "v1",
+			"groups":     []any{},
+		})
+	case path == "api/v1":
+		resources := []map[string]any{{
+			"name":       "namespaces",
+			"namespaced": false,
+			"kind":       "Namespace",
+			"verbs":      []string{"get", "list"},
+		}}
+		for resource, kind := range fakeKubeKinds {
+			resources = append(resources, map[string]any{
+				"name":       resource,
+				"namespaced": true,
+				"kind":       kind,
+				"verbs":      []string{"create", "delete", "get", "list", "patch", "update"},
+			})
+		}
+		writeKubeJSON(w, http.StatusOK, map[string]any{
+			"kind":         "APIResourceList",
+			"groupVersion": "v1",
+			"resources":    resources,
+		})
+	case strings.HasPrefix(path, "api/v1/namespaces"):
+		api.serveNamespaced(w, r, strings.Split(path, "/")[2:])
+	default:
+		writeKubeStatus(w, http.StatusNotFound, "NotFound", "", "", "the server could not find the requested resource")
+	}
+}
+
+// serveNamespaced serves paths below /api/v1/namespaces, split into segments.
+func (api *fakeKubeAPI) serveNamespaced(w http.ResponseWriter, r *http.Request, segments []string) {
+	switch len(segments) {
+	case 1:
+		writeKubeJSON(w, http.StatusOK, map[string]any{
+			"apiVersion": "v1",
+			"kind":       "NamespaceList",
+			"metadata":   map[string]any{"resourceVersion": "1"},
+			"items":      []any{map[string]any{"metadata": map[string]any{"name": "default"}}},
+		})
+		return
+	case 2:
+		writeKubeJSON(w, http.StatusOK, map[string]any{
+			"apiVersion": "v1",
+			"kind":       "Namespace",
+			"metadata":   map[string]any{"name": segments[1]},
+			"status":     map[string]any{"phase": "Active"},
+		})
+		return
+	}
+
+	namespace, resource := segments[1], segments[2]
+	kind, ok := fakeKubeKinds[resource]
+	if !ok || len(segments) > 4 {
+		writeKubeStatus(w, http.StatusNotFound, "NotFound", resource, "", "the server could not find the requested resource")
+		return
+	}
+	dryRun := r.URL.Query().Get("dryRun") == "All"
+
+	api.mu.Lock()
+	defer api.mu.Unlock()
+
+	if len(segments) == 3 {
+		switch r.Method {
+		case http.MethodGet:
+			items := []any{}
+			prefix := resource + "/" + namespace + "/"
+			for key, obj := range api.objects {
+				if strings.HasPrefix(key, prefix) {
+					items = append(items, obj)
+				}
+			}
+			writeKubeJSON(w, http.StatusOK, map[string]any{
+				"apiVersion": "v1",
+				"kind":       kind + "List",
+				"metadata":   map[string]any{"resourceVersion": "1"},
+				"items":      items,
+			})
+		case http.MethodPost:
+			var body struct {
+				Metadata struct {
+					Name string `json:"name"`
+				} `json:"metadata"`
+				Data map[string]any `json:"data"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				writeKubeStatus(w, http.StatusBadRequest, "BadRequest", resource, "", err.Error())
+				return
+			}
+			key := resource + "/" + namespace + "/" + body.Metadata.Name
+			if _, exists := api.objects[key]; exists {
+				writeKubeStatus(w, http.StatusConflict, "AlreadyExists", resource, body.Metadata.Name, "already exists")
+				return
+			}
+			obj := api.newObject(resource, namespace, body.Metadata.Name, body.Data)
+			if !dryRun {
+				api.objects[key] = obj
+			}
+			writeKubeJSON(w, http.StatusCreated, obj)
+		default:
+			writeKubeStatus(w, http.StatusMethodNotAllowed, "MethodNotAllowed", resource, "", r.Method)
+		}
+		return
+	}
+
+	name := segments[3]
+	obj, exists := api.objects[resource+"/"+namespace+"/"+name]
+	if !exists {
+		writeKubeStatus(w, http.StatusNotFound, "NotFound", resource, name, fmt.Sprintf("%s %q not found", resource, name))
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		writeKubeJSON(w, http.StatusOK, obj)
+	case http.MethodDelete:
+		if !dryRun {
+			delete(api.objects, resource+"/"+namespace+"/"+name)
+		}
+		writeKubeJSON(w, http.StatusOK, obj)
+	default:
+		writeKubeStatus(w, http.StatusMethodNotAllowed, "MethodNotAllowed", resource, name, r.Method)
+	}
+}
+
+func writeKubeJSON(w http.ResponseWriter, code int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func writeKubeStatus(w http.ResponseWriter, code int, reason, resource, name, message string) {
+	writeKubeJSON(w, code, map[string]any{
+		"apiVersion": "v1",
+		"kind":       "Status",
+		"status":     "Failure",
+		"message":    message,
+		"reason":     reason,
+		"details":    map[string]any{"name": name, "kind": resource},
+		"code":       code,
+	})
+}
+
+// writeKubeconfig writes a kubeconfig for api to dir and returns its path. user is the
+// kubeconfig's user entry as single-line JSON; an empty user connects without credentials.
+func writeKubeconfig(t *testing.T, dir string, api *fakeKubeAPI, user string) string {
+	t.Helper()
+	if user == "" {
+		user = "{}"
+	}
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: api.Certificate().Raw})
+	kubeconfig := fmt.Sprintf(`apiVersion: v1
+kind: Config
+clusters:
+- name: fake
+  cluster:
+    server: %s
+    certificate-authority-data: %s
+contexts:
+- name: fake
+  context:
+    cluster: fake
+    namespace: default
+    user: fake
+current-context: fake
+users:
+- name: fake
+  user: %s
+`, api.URL, base64.StdEncoding.EncodeToString(ca), user)
+	p := filepath.Join(dir, "kubeconfig")
+	require.NoError(t, os.WriteFile(p, []byte(kubeconfig), 0o600))
+	return p
+}
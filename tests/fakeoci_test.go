@@ -0,0 +1,287 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package containers
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeOCIRegistry is a minimal OCI distribution registry. It implements the parts of the
+// distribution spec that helm uses to push and pull charts, keeps everything in memory, requires
+// basic auth on every request and serves TLS with a self-signed certificate for localhost.
+type fakeOCIRegistry struct {
+	*httptest.Server
+
+	// CertificatePEM is the registry's self-signed certificate.
+	CertificatePEM []byte
+
+	username, password string
+
+	mu        sync.Mutex
+	blobs     map[string][]byte          // keyed by digest
+	manifests map[string]fakeOCIManifest // keyed by "<name>:<tag>" and "<name>@<digest>"
+	uploads   map[string][]byte          // keyed by upload ID
+	nextID    int
+	tags      map[string]map[string]bool // repository name to tags
+}
+
+// fakeOCIManifest is a manifest stored in fakeOCIRegistry.
+type fakeOCIManifest struct {
+	MediaType string
+	Content   []byte
+}
+
+func newFakeOCIRegistry(t *testing.T, username, password string) *fakeOCIRegistry {
+	cert, certPEM := newSelfSignedCertificate(t, "localhost", "127.0.0.1")
+	registry := &fakeOCIRegistry{
+		CertificatePEM: certPEM,
+		username:       username,
+		password:       password,
+		blobs:          map[string][]byte{},
+		manifests:      map[string]fakeOCIManifest{},
+		uploads:        map[string][]byte{},
+		tags:           map[string]map[string]bool{},
+	}
+	registry.Server = httptest.NewUnstartedServer(http.HandlerFunc(registry.serveHTTP))
+	registry.TLS = &tls.Config{Certificates: []tls.Certificate{cert}}
+	registry.StartTLS()
+	t.Cleanup(registry.Close)
+	return registry
+}
+
+// Host returns the registry's address by name, as it appears in oci:// references.
+func (registry *fakeOCIRegistry) Host() string {
+	u, err := url.Parse(registry.URL)
+	if err != nil {
+		panic(err)
+	}
+	return "localhost:" + u.Port()
+}
+
+// Tags returns the tags of the repository name, sorted.
+func (registry *fakeOCIRegistry) Tags(name string) []string {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	tags := []string{}
+	for tag := range registry.tags[name] {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+func (registry *fakeOCIRegistry) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Docker-Distribution-API-Version", "registry/2.0")
+	if username, password, ok := r.BasicAuth(); !ok || username != registry.username ||
+		password != registry.password {
+		w.Header().Set("WWW-Authenticate", `Basic realm="fake-oci-registry"`)
+		writeOCIError(w, http.StatusUnauthorized, "UNAUTHORIZED", "authentication required")
+		return
+	}
+
+	path := r.URL.Path
+	if path == "/v2/" || path == "/v2" {
+		writeOCIJSON(w, http.StatusOK, map[string]any{})
+		return
+	}
+	path = strings.TrimPrefix(path, "/v2/")
+
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	switch {
+	case strings.HasSuffix(path, "/tags/list"):
+		registry.serveTags(w, strings.TrimSuffix(path, "/tags/list"))
+	case strings.Contains(path, "/blobs/uploads"):
+		i := strings.LastIndex(path, "/blobs/uploads")
+		registry.serveUpload(w, r, path[:i], strings.Trim(path[i+len("/blobs/uploads"):], "/"))
+	case strings.Contains(path, "/blobs/"):
+		i := strings.LastIndex(path, "/blobs/")
+		registry.serveBlob(w, r, path[i+len("/blobs/"):])
+	case strings.Contains(path, "/manifests/"):
+		i := strings.LastIndex(path, "/manifests/")
+		registry.serveManifest(w, r, path[:i], path[i+len("/manifests/"):])
+	default:
+		writeOCIError(w, http.StatusNotFound, "NAME_UNKNOWN", "unknown path "+r.URL.Path)
+	}
+}
+
+func (registry *fakeOCIRegistry) serveTags(w http.ResponseWriter, name string) {
+	tags, ok := registry.tags[name]
+	if !ok {
+		writeOCIError(w, http.StatusNotFound, "NAME_UNKNOWN", "repository "+name+" not found")
+		return
+	}
+	list := []string{}
+	for tag := range tags {
+		list = append(list, tag)
+	}
+	sort.Strings(list)
+	writeOCIJSON(w, http.StatusOK, map[string]any{"name": name, "tags": list})
+}
+
+// serveUpload implements blob uploads, both monolithic and chunked. Cross repository mounts are
+// answered with a regular upload session, which the spec allows.
+func (registry *fakeOCIRegistry) serveUpload(w http.ResponseWriter, r *http.Request, name, id string) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeOCIError(w, http.StatusBadRequest, "BLOB_UPLOAD_INVALID", err.Error())
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodPost && id == "":
+		if digest := r.URL.Query().Get("digest"); digest != "" {
+			registry.commitBlob(w, name, digest, body)
+			return
+		}
+		registry.nextID++
+		id = strconv.Itoa(registry.nextID)
+		registry.uploads[id] = body
+		registry.writeUploadStatus(w, name, id, http.StatusAccepted)
+	case r.Method == http.MethodPatch && id != "":
+		data, ok := registry.uploads[id]
+		if !ok {
+			writeOCIError(w, http.StatusNotFound, "BLOB_UPLOAD_UNKNOWN", "upload "+id+" not found")
+			return
+		}
+		registry.uploads[id] = append(data, body...)
+		registry.writeUploadStatus(w, name, id, http.StatusAccepted)
+	case r.Method == http.MethodPut && id != "":
+		data, ok := registry.uploads[id]
+		if !ok {
+			writeOCIError(w, http.StatusNotFound, "BLOB_UPLOAD_UNKNOWN", "upload "+id+" not found")
+			return
+		}
+		delete(registry.uploads, id)
+		registry.commitBlob(w, name, r.URL.Query().Get("digest"), append(data, body...))
+	default:
+		writeOCIError(w, http.StatusMethodNotAllowed, "UNSUPPORTED", r.Method)
+	}
+}
+
+func (registry *fakeOCIRegistry) writeUploadStatus(w http.ResponseWriter, name, id string, code int) {
+	w.Header().Set("Location", "/v2/"+name+"/blobs/uploads/"+id)
+	w.Header().Set("Docker-Upload-UUID", id)
+	w.Header().Set("Range", fmt.Sprintf("0-%d", max(len(registry.uploads[id])-1, 0)))
+	w.Header().Set("Content-Length", "0")
+	w.WriteHeader(code)
+}
+
+func (registry *fakeOCIRegistry) commitBlob(w http.ResponseWriter, name, digest string, data []byte) {
+	if actual := ociDigest(data); digest != actual {
+		writeOCIError(w, http.StatusBadRequest, "DIGEST_INVALID",
+			fmt.Sprintf("digest %q does not match content digest %q", digest, actual))
+		return
+	}
+	registry.blobs[digest] = data
+	w.Header().Set("Location", "/v2/"+name+"/blobs/"+digest)
+	w.Header().Set("Docker-Content-Digest", digest)
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (registry *fakeOCIRegistry) serveBlob(w http.ResponseWriter, r *http.Request, digest string) {
+	data, ok := registry.blobs[digest]
+	if !ok {
+		writeOCIError(w, http.StatusNotFound, "BLOB_UNKNOWN", "blob "+digest+" not found")
+		return
+	}
+	switch r.Method {
+	case http.MethodHead, http.MethodGet:
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		w.Header().Set("Docker-Content-Digest", digest)
+		w.WriteHeader(http.StatusOK)
+		if r.Method == http.MethodGet {
+			_, _ = w.Write(data)
+		}
+	default:
+		writeOCIError(w, http.StatusMethodNotAllowed, "UNSUPPORTED", r.Method)
+	}
+}
+
+func (registry *fakeOCIRegistry) serveManifest(w http.ResponseWriter, r *http.Request, name, reference string) {
+	key := name + ":" + reference
+	if strings.HasPrefix(reference, "sha256:") {
+		key = name + "@" + reference
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		content, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeOCIError(w, http.StatusBadRequest, "MANIFEST_INVALID", err.Error())
+			return
+		}
+		digest := ociDigest(content)
+		manifest := fakeOCIManifest{MediaType: r.Header.Get("Content-Type"), Content: content}
+		registry.manifests[name+"@"+digest] = manifest
+		if !strings.HasPrefix(reference, "sha256:") {
+			registry.manifests[key] = manifest
+			if registry.tags[name] == nil {
+				registry.tags[name] = map[string]bool{}
+			}
+			registry.tags[name][reference] = true
+		}
+		w.Header().Set("Location", "/v2/"+name+"/manifests/"+digest)
+		w.Header().Set("Docker-Content-Digest", digest)
+		w.WriteHeader(http.StatusCreated)
+	case http.MethodHead, http.MethodGet:
+		manifest, ok := registry.manifests[key]
+		if !ok {
+			writeOCIError(w, http.StatusNotFound, "MANIFEST_UNKNOWN", "manifest "+reference+" not found")
+			return
+		}
+		w.Header().Set("Content-Type", manifest.MediaType)
+		w.Header().Set("Content-Length", strconv.Itoa(len(manifest.Content)))
+		w.Header().Set("Docker-Content-Digest", ociDigest(manifest.Content))
+		w.WriteHeader(http.StatusOK)
+		if r.Method == http.MethodGet {
+			_, _ = w.Write(manifest.Content)
+		}
+	default:
+		writeOCIError(w, http.StatusMethodNotAllowed, "UNSUPPORTED", r.Method)
+	}
+}
+
+func ociDigest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func writeOCIJSON(w http.ResponseWriter, code int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func writeOCIError(w http.ResponseWriter, code int, errorCode, message string) {
+	writeOCIJSON(w, code, map[string]any{
+		"errors": []map[string]string{{"code": errorCode, "message": message}},
+	})
+}
@@ -0,0 +1,242 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package containers
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// hardeningAllowlist lists the findings of TestFilesystemHardening that are expected for an image
+// variant. Each entry is a path.Match pattern, and matches a path if it matches the path or one of
+// its parent directories.
+type hardeningAllowlist struct {
+	// setID are setuid and setgid files.
+	setID []string
+	// worldWritable are world-writable directories without the sticky bit.
+	worldWritable []string
+	// foreignOwned are files owned by a different UID than expected.
+	foreignOwned []string
+}
+
+// newHardeningAllowlist returns the allowlist for the image variant under test.
+func newHardeningAllowlist(t *testing.T) hardeningAllowlist {
+	var allowlist hardeningAllowlist
+	switch {
+	case isUBI(t):
+		// util-linux, shadow-utils, pam and openssh from UBI minimal.
+		allowlist.setID = []string{
+			"/usr/bin/chage",
+			"/usr/bin/gpasswd",
+			"/usr/bin/mount",
+			"/usr/bin/newgrp",
+			"/usr/bin/passwd",
+			"/usr/bin/su",
+			"/usr/bin/umount",
+			"/usr/bin/write",
+			"/usr/libexec/openssh/ssh-keysign",
+			"/usr/sbin/pam_timestamp_check",
+			"/usr/sbin/unix_chkpwd",
+		}
+	default:
+		// login, passwd, util-linux and libpam-modules from Debian slim.
+		allowlist.setID = []string{
+			"/usr/bin/chage",
+			"/usr/bin/chfn",
+			"/usr/bin/chsh",
+			"/usr/bin/expiry",
+			"/usr/bin/gpasswd",
+			"/usr/bin/mount",
+			"/usr/bin/newgrp",
+			"/usr/bin/passwd",
+			"/usr/bin/su",
+			"/usr/bin/umount",
+			"/usr/sbin/unix_chkpwd",
+		}
+	}
+	if isKitchenSink(t) || isNonRoot(t) {
+		// Pulled in by git, docker-ce and the JDK with their recommended packages.
+		allowlist.setID = append(allowlist.setID,
+			"/usr/bin/fusermount",
+			"/usr/bin/fusermount3",
+			"/usr/bin/newgidmap",
+			"/usr/bin/newuidmap",
+			"/usr/bin/pkexec",
+			"/usr/bin/ssh-agent",
+			"/usr/bin/wall",
+			"/usr/bin/write.ul",
+			"/usr/lib/*/utempter/utempter",
+			"/usr/lib/dbus-1.0/dbus-daemon-launch-helper",
+			"/usr/lib/openssh/ssh-keysign",
+			"/usr/lib/polkit-1/polkit-agent-helper-1",
+		)
+	}
+	return allowlist
+}
+
+// TestFilesystemHardening audits the image's filesystem for setuid and setgid files, world-writable
+// directories without the sticky bit, and files under /pulumi, /usr/local/share and HOME that are
+// owned by an unexpected user. Findings that are not on the variant's allowlist fail the test, so
+// that new install scripts can't quietly weaken the image.
+//
+// Mount points are skipped, since their contents come from the host and not from the image.
+//
+// NOTE: This test is intended to be run inside the container.
+func TestFilesystemHardening(t *testing.T) {
+	t.Parallel()
+
+	allowlist := newHardeningAllowlist(t)
+	mounts := readMountPoints(t)
+
+	// Files under these directories must be owned by the given UID. HOME belongs to the user the
+	// image runs as, everything else is installed by root.
+	home, err := os.UserHomeDir()
+	require.NoError(t, err)
+	owners := map[string]uint32{
+		"/pulumi":          0,
+		"/usr/local/share": 0,
+		home:               uint32(os.Getuid()),
+	}
+
+	var setID, worldWritable, foreignOwned []string
+	err = filepath.WalkDir("/", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Other tests create and remove temporary files while we walk, and nonroot users
+			// can't read everything.
+			if errors.Is(err, fs.ErrNotExist) || errors.Is(err, fs.ErrPermission) {
+				if d != nil && d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() && p != "/" && mounts[p] {
+			return filepath.SkipDir
+		}
+		info, err := d.Info()
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		mode := info.Mode()
+
+		// Setgid directories only pass their group on to new files, e.g. /var/mail.
+		if mode.IsRegular() && mode&(fs.ModeSetuid|fs.ModeSetgid) != 0 &&
+			!allowlist.allows(allowlist.setID, p) {
+			setID = append(setID, fmt.Sprintf("%s (%s)", p, mode))
+		}
+		if mode.IsDir() && mode.Perm()&0o002 != 0 && mode&fs.ModeSticky == 0 &&
+			!allowlist.allows(allowlist.worldWritable, p) {
+			worldWritable = append(worldWritable, fmt.Sprintf("%s (%s)", p, mode))
+		}
+		if expected, ok := expectedOwner(owners, p); ok {
+			stat, ok := info.Sys().(*syscall.Stat_t)
+			require.True(t, ok, "no ownership information for %s", p)
+			if stat.Uid != expected && !allowlist.allows(allowlist.foreignOwned, p) {
+				foreignOwned = append(foreignOwned, fmt.Sprintf("%s (uid %d, expected %d)", p, stat.Uid, expected))
+			}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	// Cap the number of owner findings, a whole directory tree can be owned by the wrong user.
+	if len(foreignOwned) > 50 {
+		foreignOwned = append(foreignOwned[:50], fmt.Sprintf("... and %d more", len(foreignOwned)-50))
+	}
+	require.Empty(t, setID, "unexpected setuid or setgid files:\n%s", strings.Join(setID, "\n"))
+	require.Empty(t, worldWritable, "world-writable directories without the sticky bit:\n%s",
+		strings.Join(worldWritable, "\n"))
+	require.Empty(t, foreignOwned, "files owned by an unexpected user:\n%s", strings.Join(foreignOwned, "\n"))
+}
+
+// allows reports whether p or one of its parent directories matches one of patterns.
+func (hardeningAllowlist) allows(patterns []string, p string) bool {
+	for ; p != "/" && p != "."; p = path.Dir(p) {
+		for _, pattern := range patterns {
+			if ok, _ := path.Match(pattern, p); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// expectedOwner returns the UID that must own p, according to the directory in owners that most
+// closely contains p.
+func expectedOwner(owners map[string]uint32, p string) (uint32, bool) {
+	dirs := make([]string, 0, len(owners))
+	for dir := range owners {
+		dirs = append(dirs, dir)
+	}
+	// Longest first, so that the innermost directory wins.
+	sort.Slice(dirs, func(i, j int) bool { return len(dirs[i]) > len(dirs[j]) })
+	for _, dir := range dirs {
+		if dir != "/" && (p == dir || strings.HasPrefix(p, dir+"/")) {
+			return owners[dir], true
+		}
+	}
+	return 0, false
+}
+
+// readMountPoints returns the mount points of the test's mount namespace.
+func readMountPoints(t *testing.T) map[string]bool {
+	t.Helper()
+	f, err := os.Open("/proc/self/mountinfo")
+	require.NoError(t, err)
+	defer f.Close()
+
+	mounts := map[string]bool{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// The mount point is the fifth field, with spaces and other special characters escaped as
+		// octal sequences, see proc(5).
+		fields := strings.Fields(scanner.Text())
+		require.GreaterOrEqual(t, len(fields), 5, "malformed mountinfo line %q", scanner.Text())
+		mounts[unescapeMountPoint(fields[4])] = true
+	}
+	require.NoError(t, scanner.Err())
+	return mounts
+}
+
+func unescapeMountPoint(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+3 < len(s) {
+			if c, err := strconv.ParseUint(s[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(c))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
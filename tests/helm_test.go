@@ -0,0 +1,192 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package containers
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestHelm checks the helm setup of the kitchen sink, for root and the nonroot user. The Dockerfile
+// pins XDG_CONFIG_HOME and XDG_CACHE_HOME to the image user's home, so that helm finds the
+// `stable` repo even when HOME is changed, e.g. to /github/home in GitHub Actions.
+//
+// NOTE: This test is intended to be run inside the container.
+func TestHelm(t *testing.T) {
+	if !hasKitchenSinkTools(t) {
+		t.Skip("helm is only installed in the kitchen sink images")
+	}
+	t.Parallel()
+
+	home := expectedUser(t).Home
+
+	for _, tc := range []struct {
+		name string
+		env  []string
+	}{
+		{name: "default HOME"},
+		{name: "HOME=/github/home", env: []string{"HOME=/github/home"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			t.Run("repo list", func(t *testing.T) {
+				t.Parallel()
+
+				out := runHelm(t, tc.env, "repo", "list", "--output=json")
+				var repos []struct {
+					Name string `json:"name"`
+					URL  string `json:"url"`
+				}
+				require.NoError(t, json.Unmarshal([]byte(out), &repos), "%s", out)
+				urls := map[string]string{}
+				for _, repo := range repos {
+					urls[repo.Name] = repo.URL
+				}
+				require.Equal(t, "https://charts.helm.sh/stable", urls["stable"])
+			})
+
+			t.Run("template", func(t *testing.T) {
+				t.Parallel()
+
+				chart := filepath.Join(t.TempDir(), "demo")
+				copyTestDataDir(t, filepath.Join("testdata", "charts", "demo"), chart)
+
+				out := runHelm(t, tc.env, "template", "release", chart, "--set=greeting=hi")
+				require.Contains(t, out, "kind: ConfigMap")
+				require.Contains(t, out, "name: release-config")
+				require.Contains(t, out, "app.kubernetes.io/name: demo")
+				require.Contains(t, out, `greeting: "hi"`)
+			})
+
+			t.Run("directories", func(t *testing.T) {
+				t.Parallel()
+
+				helmEnv := parseHelmEnv(t, runHelm(t, tc.env, "env"))
+				require.Equal(t, filepath.Join(home, ".config", "helm"), helmEnv["HELM_CONFIG_HOME"])
+				require.Equal(t, filepath.Join(home, ".cache", "helm"), helmEnv["HELM_CACHE_HOME"])
+				require.Equal(t, filepath.Join(home, ".config", "helm", "repositories.yaml"),
+					helmEnv["HELM_REPOSITORY_CONFIG"])
+
+				for _, name := range []string{"HELM_CONFIG_HOME", "HELM_CACHE_HOME", "HELM_REPOSITORY_CACHE"} {
+					dir := helmEnv[name]
+					require.NoError(t, os.MkdirAll(dir, 0o755), "%s is not writable", name)
+					f, err := os.CreateTemp(dir, "writable-*")
+					require.NoError(t, err, "%s is not writable", name)
+					require.NoError(t, f.Close())
+					require.NoError(t, os.Remove(f.Name()))
+				}
+			})
+		})
+	}
+}
+
+// TestHelmOCIRegistry packages the demo chart and round trips it through an in-process OCI
+// registry, the way charts are published to private registries. The registry requires basic auth
+// and serves a self-signed certificate, which helm must trust through the CA store.
+//
+// NOTE: This test is intended to be run inside the container.
+func TestHelmOCIRegistry(t *testing.T) {
+	if !hasKitchenSinkTools(t) {
+		t.Skip("helm is only installed in the kitchen sink images")
+	}
+	t.Parallel()
+
+	const username, password = "pulumi", "correct horse battery staple"
+	registry := newFakeOCIRegistry(t, username, password)
+	dir := t.TempDir()
+	// Keep the registry credentials out of the image's helm config.
+	registryConfig := filepath.Join(dir, "registry.json")
+	env := append(trustCertificate(t, registry.CertificatePEM), "HELM_REGISTRY_CONFIG="+registryConfig)
+
+	chart := filepath.Join(dir, "demo")
+	copyTestDataDir(t, filepath.Join("testdata", "charts", "demo"), chart)
+	packages := filepath.Join(dir, "packages")
+	runHelm(t, env, "package", chart, "--destination", packages)
+	archive := filepath.Join(packages, "demo-0.1.0.tgz")
+	require.FileExists(t, archive)
+
+	// Pushing without credentials must be rejected.
+	cmd := exec.Command("helm", "push", archive, "oci://"+registry.Host()+"/charts")
+	cmd.Env = append(os.Environ(), env...)
+	out, err := cmd.CombinedOutput()
+	require.Error(t, err, "pushing without credentials succeeded: %s", out)
+	require.Empty(t, registry.Tags("charts/demo"))
+
+	runHelm(t, env, "registry", "login", registry.Host(), "--username", username, "--password", password)
+	config, err := os.ReadFile(registryConfig)
+	require.NoError(t, err)
+	require.Contains(t, string(config), registry.Host())
+
+	runHelm(t, env, "push", archive, "oci://"+registry.Host()+"/charts")
+	require.Equal(t, []string{"0.1.0"}, registry.Tags("charts/demo"))
+
+	// The chart layer is the packaged archive, so the pulled chart must match it byte for byte.
+	pulled := filepath.Join(dir, "pulled")
+	runHelm(t, env, "pull", "oci://"+registry.Host()+"/charts/demo", "--version", "0.1.0",
+		"--destination", pulled)
+	expected, err := os.ReadFile(archive)
+	require.NoError(t, err)
+	actual, err := os.ReadFile(filepath.Join(pulled, "demo-0.1.0.tgz"))
+	require.NoError(t, err)
+	require.Equal(t, expected, actual)
+
+	rendered := runHelm(t, env, "template", "release", "oci://"+registry.Host()+"/charts/demo",
+		"--version", "0.1.0", "--set=greeting=hi")
+	require.Contains(t, rendered, "name: release-config")
+	require.Contains(t, rendered, `greeting: "hi"`)
+}
+
+// runHelm runs helm with env added to the environment and returns its stdout.
+func runHelm(t *testing.T, env []string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("helm", args...)
+	cmd.Env = append(os.Environ(), env...)
+	t.Logf("Running %q", cmd.String())
+	out, err := cmd.Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		t.Logf("stderr: %s", exitErr.Stderr)
+	}
+	require.NoError(t, err)
+	return string(out)
+}
+
+// parseHelmEnv parses the output of `helm env`, which prints one NAME="value" pair per line.
+func parseHelmEnv(t *testing.T, out string) map[string]string {
+	t.Helper()
+	env := map[string]string{}
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		name, value, ok := strings.Cut(scanner.Text(), "=")
+		if !ok {
+			continue
+		}
+		unquoted, err := strconv.Unquote(value)
+		require.NoError(t, err, "parsing %q", scanner.Text())
+		env[name] = unquoted
+	}
+	require.NoError(t, scanner.Err())
+	return env
+}
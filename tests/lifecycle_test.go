@@ -0,0 +1,139 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package containers
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+	ptesting "github.com/pulumi/pulumi/sdk/v3/go/common/testing"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLifecycle runs a full update, refresh and destroy cycle against a local file backend. The
+// programs use dynamic providers, which are served by the language host itself, so the provider
+// gRPC paths are exercised without downloading any plugins.
+//
+// NOTE: This test is intended to be run inside the container.
+func TestLifecycle(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range []struct {
+		sdk          string
+		resourceType string
+	}{
+		{sdk: "typescript", resourceType: "pulumi-nodejs:dynamic:Resource"},
+		{sdk: "python", resourceType: "pulumi-python:dynamic:Resource"},
+	} {
+		test := test
+		t.Run(test.sdk, func(t *testing.T) {
+			if !hasSDK(t, test.sdk) {
+				t.Skipf("Skipping %s lifecycle test for images without its runtime", test.sdk)
+			}
+			t.Parallel()
+
+			e := ptesting.NewEnvironment(t)
+			defer e.DeleteIfNotFailed()
+			e.SetBackend(e.LocalURL())
+			e.CWD = filepath.Join(e.RootPath, "program")
+			copyTestDataDir(t, filepath.Join("testdata", "lifecycle", test.sdk), e.CWD)
+
+			managed := filepath.Join(e.RootPath, "managed.txt")
+			e.RunCommand("pulumi", "stack", "init", "dev")
+			e.RunCommand("pulumi", "config", "set", "path", managed)
+			e.RunCommand("pulumi", "config", "set", "content", "v1")
+			e.RunCommand("pulumi", "install")
+
+			// Create
+			e.RunCommand("pulumi", "up", "--yes", "--skip-preview", "--non-interactive")
+			requireFileContent(t, managed, "v1")
+			require.Equal(t, map[string]any{"path": managed, "content": "v1"}, stackOutputs(t, e))
+			file := requireSingleResource(t, e, test.resourceType)
+			require.Equal(t, managed, string(file.ID))
+			require.Equal(t, "v1", file.Outputs["content"])
+
+			// Update
+			e.RunCommand("pulumi", "config", "set", "content", "v2")
+			e.RunCommand("pulumi", "up", "--yes", "--skip-preview", "--non-interactive")
+			requireFileContent(t, managed, "v2")
+			require.Equal(t, "v2", stackOutputs(t, e)["content"])
+			file = requireSingleResource(t, e, test.resourceType)
+			require.Equal(t, "v2", file.Outputs["content"])
+
+			// Nothing changed since the last update, so the provider's diff must report no changes.
+			e.RunCommand("pulumi", "preview", "--expect-no-changes", "--non-interactive")
+
+			// Refresh picks up changes made outside of Pulumi through the provider's read.
+			require.NoError(t, os.WriteFile(managed, []byte("drifted"), 0o600))
+			e.RunCommand("pulumi", "refresh", "--yes", "--skip-preview", "--non-interactive")
+			file = requireSingleResource(t, e, test.resourceType)
+			require.Equal(t, "drifted", file.Outputs["content"])
+
+			// The next update restores the configured content.
+			e.RunCommand("pulumi", "up", "--yes", "--skip-preview", "--non-interactive")
+			requireFileContent(t, managed, "v2")
+
+			// Destroy
+			e.RunCommand("pulumi", "destroy", "--yes", "--skip-preview", "--non-interactive")
+			require.NoFileExists(t, managed)
+			require.Empty(t, exportStack(t, e).Resources)
+			require.Empty(t, stackOutputs(t, e))
+		})
+	}
+}
+
+// exportStack returns the current deployment of the selected stack.
+func exportStack(t *testing.T, e *ptesting.Environment) apitype.DeploymentV3 {
+	t.Helper()
+	stdout, _ := e.RunCommand("pulumi", "stack", "export")
+	var untyped apitype.UntypedDeployment
+	require.NoError(t, json.Unmarshal([]byte(stdout), &untyped))
+	var deployment apitype.DeploymentV3
+	require.NoError(t, json.Unmarshal(untyped.Deployment, &deployment))
+	return deployment
+}
+
+// stackOutputs returns the outputs of the selected stack.
+func stackOutputs(t *testing.T, e *ptesting.Environment) map[string]any {
+	t.Helper()
+	stdout, _ := e.RunCommand("pulumi", "stack", "output", "--json")
+	outputs := map[string]any{}
+	require.NoError(t, json.Unmarshal([]byte(stdout), &outputs))
+	return outputs
+}
+
+// requireSingleResource asserts that the stack contains exactly one resource of the given type
+// and returns it.
+func requireSingleResource(t *testing.T, e *ptesting.Environment, typ string) apitype.ResourceV3 {
+	t.Helper()
+	var found []apitype.ResourceV3
+	for _, res := range exportStack(t, e).Resources {
+		if string(res.Type) == typ {
+			found = append(found, res)
+		}
+	}
+	require.Len(t, found, 1, "expected exactly one %s resource", typ)
+	return found[0]
+}
+
+func requireFileContent(t *testing.T, path, expected string) {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, expected, string(content))
+}
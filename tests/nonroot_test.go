@@ -0,0 +1,110 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package containers
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestNonRootOwnership checks that the directories the nonroot user writes to are owned by the
+// user's UID and GID, and writable. Teams that build the image with `--build-arg UID=...
+// --build-arg GID=...` to match the owner of a bind mount on the host rely on the image setting
+// these up for the custom IDs, not for the default 1000:1000. See expectedUser for how to tell the
+// test about custom IDs.
+//
+// Directories that don't exist yet must be creatable, so their nearest existing parent is checked
+// instead.
+//
+// NOTE: This test is intended to be run inside the container.
+func TestNonRootOwnership(t *testing.T) {
+	if !isNonRoot(t) {
+		t.Skip("Only the nonroot image runs as a non-root user")
+	}
+	t.Parallel()
+
+	user := expectedUser(t)
+	require.Equal(t, user.UID, os.Getuid(), "UID")
+	require.Equal(t, user.GID, os.Getgid(), "GID")
+
+	configHome := mustEnv(t, "XDG_CONFIG_HOME")
+	cacheHome := mustEnv(t, "XDG_CACHE_HOME")
+	for _, tc := range []struct {
+		name string
+		path string
+	}{
+		{name: "HOME", path: user.Home},
+		{name: "GOPATH", path: mustEnv(t, "GOPATH")},
+		{name: "XDG_CONFIG_HOME", path: configHome},
+		{name: "XDG_CACHE_HOME", path: cacheHome},
+		{name: "helm repositories", path: filepath.Join(configHome, "helm", "repositories.yaml")},
+		{name: "helm repository cache", path: filepath.Join(cacheHome, "helm", "repository")},
+		{name: "pulumi plugins", path: filepath.Join(user.Home, ".pulumi", "plugins")},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			require.True(t, isUnder(tc.path, []string{user.Home}), "%s is outside of %s", tc.path, user.Home)
+
+			// Every existing directory from HOME down to the path must belong to the user, or
+			// the user can't create what's missing below it.
+			for p := tc.path; ; p = filepath.Dir(p) {
+				info, err := os.Stat(p)
+				if errors.Is(err, fs.ErrNotExist) {
+					t.Logf("%s doesn't exist yet", p)
+					continue
+				}
+				require.NoError(t, err)
+				requireOwnedBy(t, user, p, info)
+				// W_OK, and X_OK for directories.
+				mode := uint32(0o2)
+				if info.IsDir() {
+					mode |= 0o1
+				}
+				require.NoError(t, syscall.Access(p, mode), "%s is not writable", p)
+				if p == user.Home {
+					break
+				}
+			}
+
+			if strings.HasSuffix(tc.path, ".yaml") {
+				return
+			}
+			// Files the user creates must get the user's IDs as well.
+			require.NoError(t, os.MkdirAll(tc.path, 0o755))
+			f, err := os.CreateTemp(tc.path, "nonroot-")
+			require.NoError(t, err)
+			t.Cleanup(func() { require.NoError(t, os.Remove(f.Name())) })
+			require.NoError(t, f.Close())
+			info, err := os.Stat(f.Name())
+			require.NoError(t, err)
+			requireOwnedBy(t, user, f.Name(), info)
+		})
+	}
+}
+
+func requireOwnedBy(t *testing.T, user imageUser, path string, info fs.FileInfo) {
+	t.Helper()
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	require.True(t, ok, "no ownership information for %s", path)
+	require.Equal(t, user.UID, int(stat.Uid), "%s is not owned by UID %d", path, user.UID)
+	require.Equal(t, user.GID, int(stat.Gid), "%s is not owned by GID %d", path, user.GID)
+}
@@ -0,0 +1,301 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package containers
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// proxyOriginHost is the name the tools use for the origin server when they should go through the
+// proxy. It doesn't resolve, so a tool that ignores the proxy can't reach the origin at all.
+const proxyOriginHost = "origin.pulumi-proxy.test"
+
+// nodePackageManagerCA is the trust method for npm, pnpm, yarn and bun. Each reads one of the two
+// variables.
+func nodePackageManagerCA(_ *testing.T, caFile string) []string {
+	return []string{"npm_config_cafile=" + caFile, "NODE_EXTRA_CA_CERTS=" + caFile}
+}
+
+// nodePackageManagerAdd returns a fetch function that adds a dependency with the given package
+// manager, which fetches the package's metadata from the registry at url.
+func nodePackageManagerAdd(name string, args ...string) func(t *testing.T, dir string, env []string, url string) {
+	return func(t *testing.T, dir string, env []string, url string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "package.json"), []byte(`{"name": "proxy-test"}`), 0o600))
+		env = append(env, "npm_config_fetch_retries=0", "COREPACK_ENABLE_DOWNLOAD_PROMPT=0")
+		runWithEnv(t, dir, env, name, append(args, "catrust", "--registry", url)...)
+	}
+}
+
+// proxyTools are the tools whose proxy support is checked, with the trust method and fetch
+// command they use in TestCorporateCATrust where there is one.
+var proxyTools = []caTrustTool{
+	{
+		name:    "pulumi plugin",
+		has:     always,
+		fileEnv: sslCertFile,
+		fetch: func(t *testing.T, dir string, env []string, url string) {
+			env = append(env, "PULUMI_HOME="+filepath.Join(dir, ".pulumi"))
+			runWithEnv(t, dir, env, "pulumi", "plugin", "install", "resource", "catrust", "1.0.0", "--server", url)
+		},
+	},
+	caTrustToolNamed("npm"),
+	{
+		name:    "pnpm",
+		has:     hasNodejs,
+		fileEnv: nodePackageManagerCA,
+		fetch:   nodePackageManagerAdd("pnpm", "add"),
+	},
+	{
+		name:    "yarn",
+		has:     hasNodejs,
+		fileEnv: nodePackageManagerCA,
+		fetch:   nodePackageManagerAdd("yarn", "add", "--non-interactive"),
+	},
+	{
+		name: "bun",
+		// bun is only installed in the kitchen sink and the Debian Node.js images.
+		has: func(t *testing.T) bool {
+			return isKitchenSink(t) || isNonRoot(t) || (isDebian(t) && hasNodejs(t))
+		},
+		fileEnv: nodePackageManagerCA,
+		fetch:   nodePackageManagerAdd("bun", "add"),
+	},
+	caTrustToolNamed("pip"),
+	caTrustToolNamed("uv"),
+	caTrustToolNamed("poetry"),
+	caTrustToolNamed("go"),
+	caTrustToolNamed("maven"),
+	caTrustToolNamed("gradle"),
+	caTrustToolNamed("dotnet"),
+	caTrustToolNamed("az"),
+	caTrustToolNamed("aws"),
+	caTrustToolNamed("gcloud"),
+}
+
+// proxyUnaware are the tools that ignore HTTP_PROXY, HTTPS_PROXY and NO_PROXY. The JVM only uses
+// the http.proxyHost and https.proxyHost system properties, which Maven takes from settings.xml
+// and Gradle from gradle.properties.
+var proxyUnaware = []string{"gradle", "maven"}
+
+func caTrustToolNamed(name string) caTrustTool {
+	for _, tool := range caTrustTools {
+		if tool.name == name {
+			return tool
+		}
+	}
+	panic("unknown tool " + name)
+}
+
+// proxyResult is the row of a tool in the compatibility matrix.
+type proxyResult struct {
+	// proxied reports whether the tool sent its request for an external host through the proxy.
+	proxied string
+	// noProxy reports whether the tool connected directly to a host listed in NO_PROXY.
+	noProxy string
+}
+
+// TestProxy checks which tools send their requests through the proxy in HTTPS_PROXY and connect
+// directly to the hosts in NO_PROXY, the way corporate networks configure them. A local forward
+// proxy tunnels CONNECT requests for the origin's unresolvable name to a local origin server, which
+// uses a certificate from a test CA that each tool is told to trust.
+//
+// Tools are expected to honour the variables, except for those in proxyUnaware. The findings are
+// logged as a compatibility matrix for the image variant.
+//
+// NOTE: This test is intended to be run inside the container.
+func TestProxy(t *testing.T) {
+	t.Parallel()
+
+	ca := newTestCA(t)
+	cert := ca.Issue(t, proxyOriginHost, "127.0.0.1")
+	caFile := filepath.Join(t.TempDir(), "proxy-ca.crt")
+	require.NoError(t, os.WriteFile(caFile, ca.PEM, 0o644))
+
+	var env []string
+	for _, kv := range os.Environ() {
+		name, _, _ := strings.Cut(kv, "=")
+		switch {
+		case slices.Contains(caEnvVars, name),
+			strings.EqualFold(name, "HTTP_PROXY"), strings.EqualFold(name, "HTTPS_PROXY"),
+			strings.EqualFold(name, "ALL_PROXY"), strings.EqualFold(name, "NO_PROXY"):
+			continue
+		}
+		env = append(env, kv)
+	}
+
+	var mu sync.Mutex
+	results := map[string]proxyResult{}
+	t.Run("tools", func(t *testing.T) {
+		for _, tool := range proxyTools {
+			tool := tool
+			t.Run(tool.name, func(t *testing.T) {
+				if !tool.has(t) {
+					t.Skipf("%s is not installed in this image", tool.name)
+				}
+				t.Parallel()
+				toolEnv := append(slices.Clip(env), tool.fileEnv(t, caFile)...)
+				expected := !slices.Contains(proxyUnaware, tool.name)
+
+				// The origin's name doesn't resolve, so the request can only arrive through the proxy.
+				proxy, origin := newProxyFixture(t, cert)
+				fetchThroughProxy(t, tool, toolEnv, proxy, "", "https://"+net.JoinHostPort(proxyOriginHost, origin.Port()))
+				proxied := proxy.connects.Load() > 0 && origin.requests.Load() > 0
+
+				// The origin's address is in NO_PROXY, so the request must not go through the proxy.
+				proxy, origin = newProxyFixture(t, cert)
+				fetchThroughProxy(t, tool, toolEnv, proxy, "127.0.0.1", origin.URL)
+				direct := proxy.connects.Load() == 0 && origin.requests.Load() > 0
+
+				mu.Lock()
+				results[tool.name] = proxyResult{proxied: yesNo(proxied), noProxy: yesNo(direct)}
+				mu.Unlock()
+
+				require.Equal(t, expected, proxied, "%s: expected using the proxy to be %v", tool.name, expected)
+				require.True(t, direct, "%s: expected a direct connection to a host in NO_PROXY", tool.name)
+			})
+		}
+	})
+
+	var matrix strings.Builder
+	fmt.Fprintf(&matrix, "Proxy compatibility for %s:\n", mustEnv(t, "IMAGE_VARIANT"))
+	fmt.Fprintf(&matrix, "%-16s %-8s %-8s\n", "TOOL", "PROXY", "NO_PROXY")
+	for _, tool := range proxyTools {
+		result, ok := results[tool.name]
+		if !ok {
+			result = proxyResult{proxied: "n/a", noProxy: "n/a"}
+		}
+		fmt.Fprintf(&matrix, "%-16s %-8s %-8s\n", tool.name, result.proxied, result.noProxy)
+	}
+	t.Log(matrix.String())
+}
+
+// fetchThroughProxy runs the tool's fetch command with the proxy variables pointing at proxy.
+func fetchThroughProxy(t *testing.T, tool caTrustTool, env []string, proxy *forwardProxy, noProxy, url string) {
+	t.Helper()
+	env = append(slices.Clip(env),
+		"HTTP_PROXY="+proxy.URL,
+		"HTTPS_PROXY="+proxy.URL,
+		"http_proxy="+proxy.URL,
+		"https_proxy="+proxy.URL,
+		"NO_PROXY="+noProxy,
+		"no_proxy="+noProxy,
+	)
+	tool.fetch(t, t.TempDir(), env, url)
+}
+
+// forwardProxy is an HTTP proxy that tunnels CONNECT requests for the origin to the origin server.
+// Requests for other hosts, e.g. update checks, are refused and not counted.
+type forwardProxy struct {
+	*httptest.Server
+	origin   string
+	connects atomic.Int32
+}
+
+// proxyOrigin is an HTTPS server that counts the requests that reach it.
+type proxyOrigin struct {
+	*httptest.Server
+	requests atomic.Int32
+}
+
+// Port returns the port the origin listens on.
+func (origin *proxyOrigin) Port() string {
+	_, port, err := net.SplitHostPort(origin.Listener.Addr().String())
+	if err != nil {
+		panic(err)
+	}
+	return port
+}
+
+func newProxyFixture(t *testing.T, cert tls.Certificate) (*forwardProxy, *proxyOrigin) {
+	t.Helper()
+	origin := &proxyOrigin{}
+	origin.Server = httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin.requests.Add(1)
+		http.NotFound(w, r)
+	}))
+	origin.TLS = &tls.Config{Certificates: []tls.Certificate{cert}}
+	origin.StartTLS()
+	t.Cleanup(origin.Close)
+
+	proxy := &forwardProxy{origin: origin.Listener.Addr().String()}
+	proxy.Server = httptest.NewServer(http.HandlerFunc(proxy.serveHTTP))
+	t.Cleanup(proxy.Close)
+	return proxy, origin
+}
+
+func (proxy *forwardProxy) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodConnect {
+		http.Error(w, "only CONNECT is supported", http.StatusMethodNotAllowed)
+		return
+	}
+	_, port, err := net.SplitHostPort(r.Host)
+	if err != nil || (r.Host != proxy.origin && r.Host != net.JoinHostPort(proxyOriginHost, port)) {
+		http.Error(w, "unknown host "+r.Host, http.StatusForbidden)
+		return
+	}
+	proxy.connects.Add(1)
+
+	upstream, err := net.Dial("tcp", proxy.origin)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer upstream.Close()
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "hijacking not supported", http.StatusInternalServerError)
+		return
+	}
+	client, buffered, err := hijacker.Hijack()
+	if err != nil {
+		return
+	}
+	defer client.Close()
+	if _, err := client.Write([]byte("HTTP/1.1 200 Connection Established\r\n\r\n")); err != nil {
+		return
+	}
+
+	done := make(chan struct{}, 2)
+	go func() {
+		_, _ = io.Copy(upstream, buffered)
+		done <- struct{}{}
+	}()
+	go func() {
+		_, _ = io.Copy(client, upstream)
+		done <- struct{}{}
+	}()
+	<-done
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
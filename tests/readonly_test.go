@@ -0,0 +1,288 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package containers
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"math/rand/v2"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestReadOnlyRootFilesystem reports the paths outside /tmp and HOME that `pulumi install` and
+// `pulumi preview` write to, for each language the image supports. Kubernetes clusters that
+// enforce `readOnlyRootFilesystem: true` only leave /tmp, HOME and the project volume writable, so
+// every reported path is a write that fails there, e.g. fnm aliases, pyenv shims or a cache that
+// doesn't live in HOME.
+//
+// Each step runs with its own HOME, PULUMI_HOME and TMPDIR. When the test runs as root, each step
+// also runs with its own primary GID, so that the files it creates can be told apart from the ones
+// other tests create in parallel. Files that a step modifies in place, or creates in a setgid
+// directory, keep their group and are not detected. A non-root user can't write outside of HOME
+// and the world-writable directories anyway, so as non-root the test only checks that the steps
+// succeed.
+//
+// The findings are logged and don't fail the test, except for the ones that are not in
+// readOnlyAllowlist when READ_ONLY_ROOT_FILESYSTEM=strict, which CI sets.
+//
+// NOTE: This test is intended to be run inside the container.
+func TestReadOnlyRootFilesystem(t *testing.T) {
+	t.Parallel()
+	home, err := os.UserHomeDir()
+	require.NoError(t, err)
+	// The project volume is a temporary directory, see newReadOnlyEnv.
+	writable := []string{"/tmp", os.TempDir(), home}
+	mounts := readMountPoints(t)
+	allowlist := readOnlyAllowlist(t)
+	strict := os.Getenv("READ_ONLY_ROOT_FILESYSTEM") == "strict"
+
+	type step struct {
+		name string
+		run  func(t *testing.T, e *readOnlyEnv)
+	}
+	var steps []step
+	dirs, err := testdata.ReadDir("testdata/templates")
+	require.NoError(t, err)
+	for _, dir := range dirs {
+		sdk := dir.Name()
+		if !hasSDK(t, sdk) {
+			continue
+		}
+		if sdk == "csharp" && os.Getenv("LANGUAGE_VERSION") == "6.0" {
+			// The template targets net8.0, which the .NET 6.0 SDK cannot build.
+			continue
+		}
+		steps = append(steps, step{name: sdk, run: func(t *testing.T, e *readOnlyEnv) { runTemplate(t, e, sdk) }})
+	}
+	if hasKitchenSinkTools(t) {
+		steps = append(steps, step{name: "helm", run: func(t *testing.T, e *readOnlyEnv) {
+			chart := filepath.Join(e.root, "demo")
+			copyTestDataDir(t, filepath.Join("testdata", "charts", "demo"), chart)
+			e.run(t, e.root, "helm", "template", "release", chart)
+		}})
+	}
+
+	var mu sync.Mutex
+	report := map[string][]string{}
+	t.Cleanup(func() {
+		var summary strings.Builder
+		fmt.Fprintf(&summary, "Read-only root filesystem report for %s:\n", mustEnv(t, "IMAGE_VARIANT"))
+		for _, s := range steps {
+			if writes, ok := report[s.name]; ok {
+				fmt.Fprintf(&summary, "%-12s %d paths\n", s.name, len(writes))
+			} else {
+				fmt.Fprintf(&summary, "%-12s not checked\n", s.name)
+			}
+		}
+		t.Log(summary.String())
+	})
+
+	// GIDs far above the ones that images and hosts allocate, one per step.
+	gid := 1<<30 + rand.Uint32N(1<<20)
+	for i, s := range steps {
+		t.Run(s.name, func(t *testing.T) {
+			t.Parallel()
+			e := newReadOnlyEnv(t, gid+uint32(i))
+			s.run(t, e)
+			if e.credential == nil {
+				return
+			}
+
+			var writes, unexpected []string
+			for _, w := range findWritesByGID(t, e.credential.Gid, writable, mounts) {
+				writes = append(writes, w.String())
+				if !isUnder(w.path, allowlist) {
+					unexpected = append(unexpected, w.String())
+				}
+			}
+			mu.Lock()
+			report[s.name] = writes
+			mu.Unlock()
+			if len(writes) > 0 {
+				t.Logf("%s wrote outside of /tmp and %s:\n%s", s.name, home, strings.Join(writes, "\n"))
+			}
+			if strict {
+				require.Empty(t, unexpected, "writes that fail on a read-only root filesystem")
+			}
+		})
+	}
+}
+
+// readOnlyAllowlist returns the directories outside of /tmp and HOME that the image variant under
+// test is expected to write to. Deployments to read-only root filesystems have to mount a volume
+// over each of them.
+func readOnlyAllowlist(t *testing.T) []string {
+	var allowlist []string
+	// The Go toolchain downloads modules to GOPATH/pkg/mod, and GOPATH is /go outside of the
+	// nonroot image.
+	if gopath := os.Getenv("GOPATH"); hasGo(t) && gopath != "" {
+		allowlist = append(allowlist, gopath)
+	}
+	return allowlist
+}
+
+// readOnlyEnv runs the commands of a TestReadOnlyRootFilesystem step.
+type readOnlyEnv struct {
+	// root is the project volume, with the step's HOME and TMPDIR in it.
+	root string
+	env  []string
+	// credential is the step's identity when the test runs as root, and nil otherwise.
+	credential *syscall.Credential
+}
+
+// newReadOnlyEnv returns an environment with its own HOME, PULUMI_HOME and TMPDIR. When the test
+// runs as root, commands run with gid as their primary group.
+func newReadOnlyEnv(t *testing.T, gid uint32) *readOnlyEnv {
+	t.Helper()
+	root := t.TempDir()
+	home := filepath.Join(root, "home")
+	tmp := filepath.Join(root, "tmp")
+	backend := filepath.Join(root, "backend")
+	for _, dir := range []string{home, tmp, backend} {
+		require.NoError(t, os.Mkdir(dir, 0o755))
+	}
+
+	e := &readOnlyEnv{
+		root: root,
+		// Later values take precedence, see exec.Cmd.Env.
+		env: append(os.Environ(),
+			"HOME="+home,
+			"PULUMI_HOME="+filepath.Join(home, ".pulumi"),
+			"XDG_CONFIG_HOME="+filepath.Join(home, ".config"),
+			"XDG_CACHE_HOME="+filepath.Join(home, ".cache"),
+			"XDG_DATA_HOME="+filepath.Join(home, ".local", "share"),
+			"TMPDIR="+tmp,
+			"PULUMI_BACKEND_URL=file://"+backend,
+			"PULUMI_CONFIG_PASSPHRASE=correct horse battery staple",
+			"PULUMI_SKIP_UPDATE_CHECK=true",
+		),
+	}
+	if os.Getuid() == 0 {
+		groups, err := os.Getgroups()
+		require.NoError(t, err)
+		credential := &syscall.Credential{Uid: 0, Gid: gid}
+		// Keep the groups root has, so that the step can still use what they grant access to.
+		for _, g := range append(groups, os.Getgid()) {
+			credential.Groups = append(credential.Groups, uint32(g))
+		}
+		e.credential = credential
+	}
+	return e
+}
+
+// run runs a command in dir and fails the test if it fails.
+func (e *readOnlyEnv) run(t *testing.T, dir string, name string, args ...string) {
+	t.Helper()
+	cmd := exec.Command(name, args...)
+	cmd.Dir = dir
+	cmd.Env = e.env
+	if e.credential != nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{Credential: e.credential}
+	}
+	t.Logf("Running command %s %s", name, strings.Join(args, " "))
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, "%s", out)
+}
+
+// runTemplate creates a project from the local template for sdk, installs its dependencies and
+// previews it, the way a deployment in Kubernetes would.
+func runTemplate(t *testing.T, e *readOnlyEnv, sdk string) {
+	t.Helper()
+	template := filepath.Join(e.root, "template")
+	copyTestDataDir(t, filepath.Join("testdata", "templates", sdk), template)
+	project := filepath.Join(e.root, "project")
+	require.NoError(t, os.Mkdir(project, 0o755))
+
+	e.run(t, project, "pulumi", "new", template, "--yes", "--force", "--name", "readonly-"+sdk, "--stack", "dev")
+	e.run(t, project, "pulumi", "install")
+	e.run(t, project, "pulumi", "preview", "--non-interactive")
+}
+
+// fsWrite is a path that a TestReadOnlyRootFilesystem step created.
+type fsWrite struct {
+	path string
+	// entries is the number of entries below path, or -1 if path is not a directory.
+	entries int
+}
+
+func (w fsWrite) String() string {
+	if w.entries < 0 {
+		return w.path
+	}
+	return fmt.Sprintf("%s/ (%d entries)", w.path, w.entries)
+}
+
+// findWritesByGID returns the paths outside of the writable directories and the mount points whose
+// group is gid. New directories are reported once, with the number of entries below them.
+func findWritesByGID(t *testing.T, gid uint32, writable []string, mounts map[string]bool) []fsWrite {
+	t.Helper()
+	var writes []fsWrite
+	err := filepath.WalkDir("/", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) || errors.Is(err, fs.ErrPermission) {
+				if d != nil && d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() && p != "/" && (mounts[p] || isUnder(p, writable)) {
+			return filepath.SkipDir
+		}
+		info, err := d.Info()
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if stat, ok := info.Sys().(*syscall.Stat_t); !ok || stat.Gid != gid {
+			return nil
+		}
+		if !d.IsDir() {
+			writes = append(writes, fsWrite{path: p, entries: -1})
+			return nil
+		}
+		n := -1
+		_ = filepath.WalkDir(p, func(string, fs.DirEntry, error) error {
+			n++
+			return nil
+		})
+		writes = append(writes, fsWrite{path: p, entries: n})
+		return filepath.SkipDir
+	})
+	require.NoError(t, err)
+	return writes
+}
+
+// isUnder reports whether p is one of dirs or inside one of them.
+func isUnder(p string, dirs []string) bool {
+	for _, dir := range dirs {
+		if p == dir || strings.HasPrefix(p, strings.TrimSuffix(dir, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,527 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package containers
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// cycloneDXBOM is the subset of a CycloneDX 1.5 BOM that we emit, see
+// https://cyclonedx.org/docs/1.5/json/.
+type cycloneDXBOM struct {
+	BOMFormat    string            `json:"bomFormat"`
+	SpecVersion  string            `json:"specVersion"`
+	SerialNumber string            `json:"serialNumber"`
+	Version      int               `json:"version"`
+	Metadata     cycloneDXMetadata `json:"metadata"`
+	Components   []sbomComponent   `json:"components"`
+}
+
+type cycloneDXMetadata struct {
+	Timestamp string        `json:"timestamp"`
+	Tools     []sbomTool    `json:"tools"`
+	Component sbomComponent `json:"component"`
+}
+
+type sbomTool struct {
+	Name string `json:"name"`
+}
+
+type sbomComponent struct {
+	Type       string         `json:"type"`
+	BOMRef     string         `json:"bom-ref,omitempty"`
+	Name       string         `json:"name"`
+	Version    string         `json:"version,omitempty"`
+	PURL       string         `json:"purl,omitempty"`
+	Properties []sbomProperty `json:"properties,omitempty"`
+}
+
+type sbomProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// sbomPathProperty records where a component is installed in the image.
+const sbomPathProperty = "pulumi:path"
+
+// TestSBOM inventories the OS packages, language runtimes and tools installed in the image, and
+// emits a CycloneDX SBOM of them. Container scanners only look at the dpkg and rpm databases, so
+// the pyenv Pythons, fnm Node versions, global npm packages, .NET SDKs, the Go toolchain and the
+// Python tools would otherwise be invisible to security reviews.
+//
+// The SBOM is written to SBOM_OUTPUT if it is set, and is checked against the versions the image
+// declares in its Dockerfile and build arguments.
+//
+// NOTE: This test is intended to be run inside the container.
+func TestSBOM(t *testing.T) {
+	t.Parallel()
+
+	bom := generateSBOM(t)
+	if output := os.Getenv("SBOM_OUTPUT"); output != "" {
+		data, err := json.MarshalIndent(bom, "", "  ")
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(output, append(data, '\n'), 0o644))
+		t.Logf("Wrote SBOM with %d components to %s", len(bom.Components), output)
+	}
+
+	t.Run("structure", func(t *testing.T) {
+		refs := map[string]bool{}
+		for _, c := range bom.Components {
+			require.NotEmpty(t, c.Name, "component without a name: %+v", c)
+			require.NotEmpty(t, c.Version, "component %s without a version", c.Name)
+			require.True(t, strings.HasPrefix(c.PURL, "pkg:"), "component %s has an invalid purl %q", c.Name, c.PURL)
+			require.False(t, refs[c.BOMRef], "duplicate bom-ref %q", c.BOMRef)
+			refs[c.BOMRef] = true
+		}
+		require.NotEmpty(t, componentsOfType(bom, "deb", "rpm"), "no OS packages")
+		require.Len(t, componentVersions(bom, "pulumi"), 1)
+	})
+
+	languageVersion := os.Getenv("LANGUAGE_VERSION") // Not set for kitchen sink
+
+	if isKitchenSink(t) || isNonRoot(t) {
+		t.Run("kitchen sink", func(t *testing.T) {
+			require.Equal(t, []string{mustEnv(t, "GOLANG_VERSION")}, componentVersions(bom, "go"))
+			requireReleaseLines(t, []string{"3.10", "3.11", "3.12", "3.13", "3.14"}, componentVersions(bom, "python"), 2)
+			requireReleaseLines(t, []string{"22", "24", "26"}, componentVersions(bom, "node"), 1)
+			requireReleaseLines(t, []string{"8.0", "9.0", "10.0"}, componentVersions(bom, "dotnet-sdk"), 2)
+			require.NotEmpty(t, componentVersions(bom, "corepack"))
+			require.NotEmpty(t, componentVersions(bom, "bun"))
+			require.NotEmpty(t, componentVersions(bom, "poetry"))
+			require.NotEmpty(t, componentVersions(bom, "uv"))
+			// Corepack keeps the package managers in root's cache, which the nonroot user can't read.
+			if !isNonRoot(t) {
+				requireReleaseLines(t, []string{"10"}, componentVersions(bom, "pnpm"), 1)
+				requireReleaseLines(t, []string{"1"}, componentVersions(bom, "yarn"), 1)
+			}
+		})
+		return
+	}
+
+	switch {
+	case hasPython(t):
+		requireReleaseLines(t, []string{languageVersion}, componentVersions(bom, "python"), 2)
+		if v := os.Getenv("PYTHON_VERSION"); v != "" {
+			require.Contains(t, componentVersions(bom, "python"), v)
+		}
+		require.NotEmpty(t, componentVersions(bom, "poetry"))
+		require.NotEmpty(t, componentVersions(bom, "uv"))
+	case hasNodejs(t):
+		requireReleaseLines(t, []string{languageVersion}, componentVersions(bom, "node"), 1)
+		if v := os.Getenv("NPM_VERSION"); v != "" {
+			require.Contains(t, componentVersions(bom, "npm"), v)
+		}
+		requireReleaseLines(t, []string{"10"}, componentVersions(bom, "pnpm"), 1)
+		requireReleaseLines(t, []string{"1"}, componentVersions(bom, "yarn"), 1)
+	case hasDotnet(t):
+		requireReleaseLines(t, []string{languageVersion}, componentVersions(bom, "dotnet-sdk"), 2)
+	case hasGo(t):
+		out, err := exec.Command("go", "env", "GOVERSION").Output()
+		require.NoError(t, err)
+		require.Equal(t, []string{strings.TrimPrefix(strings.TrimSpace(string(out)), "go")},
+			componentVersions(bom, "go"))
+	case hasJava(t):
+		if v := os.Getenv("MAVEN_VERSION"); v != "" {
+			require.Contains(t, componentVersions(bom, "maven"), v)
+		}
+		if v := os.Getenv("GRADLE_VERSION"); v != "" {
+			require.Contains(t, componentVersions(bom, "gradle"), v)
+		}
+	}
+}
+
+// generateSBOM inspects the image and returns its SBOM.
+func generateSBOM(t *testing.T) cycloneDXBOM {
+	t.Helper()
+
+	var components []sbomComponent
+	components = append(components, dpkgComponents(t)...)
+	components = append(components, rpmComponents(t)...)
+	components = append(components, pythonComponents(t)...)
+	components = append(components, nodeComponents(t)...)
+	components = append(components, dotnetComponents(t)...)
+	components = append(components, goComponents(t)...)
+	components = append(components, javaComponents(t)...)
+	components = append(components, toolComponents(t)...)
+	sort.SliceStable(components, func(i, j int) bool { return components[i].BOMRef < components[j].BOMRef })
+
+	return cycloneDXBOM{
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  "1.5",
+		SerialNumber: newSerialNumber(t),
+		Version:      1,
+		Metadata: cycloneDXMetadata{
+			Timestamp: time.Now().UTC().Format(time.RFC3339),
+			Tools:     []sbomTool{{Name: "pulumi-docker-containers tests"}},
+			Component: sbomComponent{
+				Type: "container",
+				Name: mustEnv(t, "IMAGE_VARIANT"),
+			},
+		},
+		Components: components,
+	}
+}
+
+// newComponent returns a component for a package of the given purl type, e.g. "npm" or "generic".
+func newComponent(purlType, name, version, path string) sbomComponent {
+	purl := fmt.Sprintf("pkg:%s/%s@%s", purlType, name, url.PathEscape(version))
+	c := sbomComponent{
+		Type:    "application",
+		BOMRef:  purl,
+		Name:    name,
+		Version: version,
+		PURL:    purl,
+	}
+	if path != "" {
+		// The same version can be installed in several places, e.g. npm in every Node version.
+		c.BOMRef += "#" + path
+		c.Properties = []sbomProperty{{Name: sbomPathProperty, Value: path}}
+	}
+	return c
+}
+
+// dpkgComponents returns the packages installed according to the dpkg database.
+func dpkgComponents(t *testing.T) []sbomComponent {
+	t.Helper()
+	f, err := os.Open("/var/lib/dpkg/status")
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	require.NoError(t, err)
+	defer f.Close()
+
+	var components []sbomComponent
+	fields := map[string]string{}
+	flush := func() {
+		if strings.HasSuffix(fields["Status"], " installed") {
+			purl := fmt.Sprintf("pkg:deb/debian/%s@%s?arch=%s",
+				fields["Package"], url.PathEscape(fields["Version"]), fields["Architecture"])
+			components = append(components, sbomComponent{
+				Type:    "library",
+				BOMRef:  purl,
+				Name:    fields["Package"],
+				Version: fields["Version"],
+				PURL:    purl,
+			})
+		}
+		fields = map[string]string{}
+	}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			flush()
+			continue
+		}
+		// Continuation lines of multi-line fields, e.g. Description, start with a space.
+		if key, value, ok := strings.Cut(line, ": "); ok && !strings.HasPrefix(line, " ") {
+			fields[key] = value
+		}
+	}
+	require.NoError(t, scanner.Err())
+	flush()
+	return components
+}
+
+// rpmComponents returns the packages installed according to the rpm database.
+func rpmComponents(t *testing.T) []sbomComponent {
+	t.Helper()
+	if _, err := exec.LookPath("rpm"); err != nil {
+		return nil
+	}
+	out, err := exec.Command("rpm", "--query", "--all",
+		"--queryformat", `%{NAME}\t%{VERSION}-%{RELEASE}\t%{ARCH}\n`).Output()
+	require.NoError(t, err)
+
+	var components []sbomComponent
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		fields := strings.Split(line, "\t")
+		require.Len(t, fields, 3, "unexpected rpm output %q", line)
+		// gpg-pubkey entries are the keys trusted by rpm, not packages.
+		if fields[0] == "gpg-pubkey" {
+			continue
+		}
+		purl := fmt.Sprintf("pkg:rpm/redhat/%s@%s?arch=%s", fields[0], url.PathEscape(fields[1]), fields[2])
+		components = append(components, sbomComponent{
+			Type:    "library",
+			BOMRef:  purl,
+			Name:    fields[0],
+			Version: fields[1],
+			PURL:    purl,
+		})
+	}
+	return components
+}
+
+// pythonComponents returns the pyenv Python builds, the image's own Python if it isn't managed by
+// pyenv, and Poetry and uv.
+func pythonComponents(t *testing.T) []sbomComponent {
+	t.Helper()
+	var components []sbomComponent
+	for _, dir := range globDirs(t, "/usr/local/share/pyenv/versions/*") {
+		components = append(components, newComponent("generic", "python", filepath.Base(dir), dir))
+	}
+	// The Debian Python images are built from the official Python image, which installs into
+	// /usr/local.
+	if _, err := os.Stat("/usr/local/bin/python3"); err == nil && len(components) == 0 {
+		out, err := exec.Command("/usr/local/bin/python3", "--version").Output()
+		require.NoError(t, err)
+		version := strings.TrimPrefix(strings.TrimSpace(string(out)), "Python ")
+		components = append(components, newComponent("generic", "python", version, "/usr/local"))
+	}
+
+	for _, dir := range globDirs(t, "/usr/local/share/pypoetry/venv/lib/python*/site-packages/poetry-*.dist-info") {
+		version := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(dir), "poetry-"), ".dist-info")
+		components = append(components, newComponent("pypi", "poetry", version, "/usr/local/share/pypoetry"))
+	}
+	if _, err := os.Stat("/usr/local/share/uv/uv"); err == nil {
+		// uv prints e.g. "uv 0.8.0 (0b2357294 2025-07-17)".
+		out, err := exec.Command("/usr/local/share/uv/uv", "--version").Output()
+		require.NoError(t, err)
+		fields := strings.Fields(string(out))
+		require.GreaterOrEqual(t, len(fields), 2, "unexpected uv version %q", out)
+		components = append(components, newComponent("pypi", "uv", fields[1], "/usr/local/share/uv"))
+	}
+	return components
+}
+
+// nodeComponents returns the Node.js installations, the npm packages installed globally into each
+// of them, and the package managers that corepack has downloaded.
+func nodeComponents(t *testing.T) []sbomComponent {
+	t.Helper()
+	installations := globDirs(t, "/usr/local/share/fnm/node-versions/*/installation")
+	// The Debian Node.js images are built from the official Node.js image, which installs into
+	// /usr/local.
+	if _, err := os.Stat("/usr/local/bin/node"); err == nil {
+		installations = append(installations, "/usr/local")
+	}
+
+	var components []sbomComponent
+	for _, dir := range installations {
+		out, err := exec.Command(filepath.Join(dir, "bin", "node"), "--version").Output()
+		require.NoError(t, err)
+		version := strings.TrimPrefix(strings.TrimSpace(string(out)), "v")
+		components = append(components, newComponent("generic", "node", version, dir))
+
+		// Global packages, including scoped packages such as @pnpm/exe.
+		modules := filepath.Join(dir, "lib", "node_modules")
+		manifests := append(globDirs(t, filepath.Join(modules, "*", "package.json")),
+			globDirs(t, filepath.Join(modules, "@*", "*", "package.json"))...)
+		for _, manifest := range manifests {
+			if c, ok := npmComponent(t, manifest); ok {
+				components = append(components, c)
+			}
+		}
+	}
+
+	for _, dir := range corepackDirs() {
+		manifests := append(globDirs(t, filepath.Join(dir, "v1", "*", "*", "package.json")),
+			globDirs(t, filepath.Join(dir, "*", "*", "package.json"))...)
+		for _, manifest := range manifests {
+			if c, ok := npmComponent(t, manifest); ok {
+				components = append(components, c)
+			}
+		}
+	}
+	return components
+}
+
+// corepackDirs returns the directories corepack may have downloaded package managers to.
+func corepackDirs() []string {
+	var dirs []string
+	if dir := os.Getenv("COREPACK_HOME"); dir != "" {
+		dirs = append(dirs, dir)
+	}
+	if dir := os.Getenv("XDG_CACHE_HOME"); dir != "" {
+		dirs = append(dirs, filepath.Join(dir, "node", "corepack"))
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, filepath.Join(home, ".cache", "node", "corepack"))
+	}
+	// Package managers installed with `corepack install -g` during the image build.
+	dirs = append(dirs, "/root/.cache/node/corepack")
+
+	var unique []string
+	seen := map[string]bool{}
+	for _, dir := range dirs {
+		if !seen[dir] {
+			seen[dir] = true
+			unique = append(unique, dir)
+		}
+	}
+	return unique
+}
+
+// npmComponent returns the component for the npm package with the given package.json.
+func npmComponent(t *testing.T, manifest string) (sbomComponent, bool) {
+	t.Helper()
+	data, err := os.ReadFile(manifest)
+	if errors.Is(err, fs.ErrPermission) {
+		return sbomComponent{}, false
+	}
+	require.NoError(t, err)
+	var pkg struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}
+	require.NoError(t, json.Unmarshal(data, &pkg), "parsing %s", manifest)
+	if pkg.Name == "" || pkg.Version == "" {
+		return sbomComponent{}, false
+	}
+	// Scoped names keep their @ in the purl namespace, encoded as %40.
+	name := strings.Replace(pkg.Name, "@", "%40", 1)
+	c := newComponent("npm", name, pkg.Version, filepath.Dir(manifest))
+	c.Name = pkg.Name
+	return c, true
+}
+
+// dotnetComponents returns the .NET SDKs and runtimes in DOTNET_ROOT.
+func dotnetComponents(t *testing.T) []sbomComponent {
+	t.Helper()
+	root := os.Getenv("DOTNET_ROOT")
+	if root == "" {
+		return nil
+	}
+	var components []sbomComponent
+	for _, dir := range globDirs(t, filepath.Join(root, "sdk", "*")) {
+		components = append(components, newComponent("generic", "dotnet-sdk", filepath.Base(dir), dir))
+	}
+	for _, dir := range globDirs(t, filepath.Join(root, "shared", "Microsoft.NETCore.App", "*")) {
+		components = append(components, newComponent("generic", "dotnet-runtime", filepath.Base(dir), dir))
+	}
+	return components
+}
+
+// goComponents returns the Go toolchain.
+func goComponents(t *testing.T) []sbomComponent {
+	t.Helper()
+	if _, err := exec.LookPath("go"); err != nil {
+		return nil
+	}
+	out, err := exec.Command("go", "env", "GOROOT").Output()
+	require.NoError(t, err)
+	goroot := strings.TrimSpace(string(out))
+	data, err := os.ReadFile(filepath.Join(goroot, "VERSION"))
+	require.NoError(t, err)
+	// The first line of VERSION is e.g. "go1.26.0", followed by the build time.
+	version, _, _ := strings.Cut(string(data), "\n")
+	return []sbomComponent{newComponent("golang", "go", strings.TrimPrefix(version, "go"), goroot)}
+}
+
+// javaComponents returns the build tools installed with SDKMAN!.
+func javaComponents(t *testing.T) []sbomComponent {
+	t.Helper()
+	var components []sbomComponent
+	for _, tool := range []string{"maven", "gradle"} {
+		for _, dir := range globDirs(t, filepath.Join("/root/.sdkman/candidates", tool, "*")) {
+			if filepath.Base(dir) == "current" {
+				continue
+			}
+			components = append(components, newComponent("generic", tool, filepath.Base(dir), dir))
+		}
+	}
+	return components
+}
+
+// toolComponents returns the Pulumi CLI.
+func toolComponents(t *testing.T) []sbomComponent {
+	t.Helper()
+	path, err := exec.LookPath("pulumi")
+	require.NoError(t, err)
+	out, err := exec.Command(path, "version").Output()
+	require.NoError(t, err)
+	version := strings.TrimPrefix(strings.TrimSpace(string(out)), "v")
+	return []sbomComponent{newComponent("generic", "pulumi", version, filepath.Dir(path))}
+}
+
+// globDirs returns the paths matching pattern. Directories the current user can't read have no
+// matches.
+func globDirs(t *testing.T, pattern string) []string {
+	t.Helper()
+	matches, err := filepath.Glob(pattern)
+	require.NoError(t, err)
+	return matches
+}
+
+// componentVersions returns the sorted, distinct versions of the components with the given name.
+func componentVersions(bom cycloneDXBOM, name string) []string {
+	seen := map[string]bool{}
+	var versions []string
+	for _, c := range bom.Components {
+		if c.Name == name && !seen[c.Version] {
+			seen[c.Version] = true
+			versions = append(versions, c.Version)
+		}
+	}
+	sort.Strings(versions)
+	return versions
+}
+
+// componentsOfType returns the components whose purl has one of the given types.
+func componentsOfType(bom cycloneDXBOM, purlTypes ...string) []sbomComponent {
+	var components []sbomComponent
+	for _, c := range bom.Components {
+		for _, purlType := range purlTypes {
+			if strings.HasPrefix(c.PURL, "pkg:"+purlType+"/") {
+				components = append(components, c)
+			}
+		}
+	}
+	return components
+}
+
+// requireReleaseLines checks that versions contains a version of each of the expected release lines,
+// where a release line is the first parts components of a version, e.g. 3.12 for 3.12.4.
+func requireReleaseLines(t *testing.T, expected, versions []string, parts int) {
+	t.Helper()
+	lines := map[string]bool{}
+	for _, v := range versions {
+		fields := strings.SplitN(v, ".", parts+1)
+		if len(fields) > parts {
+			fields = fields[:parts]
+		}
+		lines[strings.Join(fields, ".")] = true
+	}
+	for _, line := range expected {
+		require.True(t, lines[line], "no %s release found in %v", line, versions)
+	}
+}
+
+// newSerialNumber returns a random URN UUID, as CycloneDX expects for serialNumber.
+func newSerialNumber(t *testing.T) string {
+	t.Helper()
+	var b [16]byte
+	_, err := rand.Read(b[:])
+	require.NoError(t, err)
+	b[6] = b[6]&0x0f | 0x40 // Version 4
+	b[8] = b[8]&0x3f | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("urn:uuid:%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
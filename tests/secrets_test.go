@@ -0,0 +1,335 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package containers
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// ciEnvVars are the environment variables that the CI workflows pass to the test container with
+// `docker run -e`. They are not part of the image, and many of them hold real credentials.
+var ciEnvVars = []string{
+	"ARM_CLIENT_ID",
+	"ARM_CLIENT_SECRET",
+	"ARM_SUBSCRIPTION_ID",
+	"ARM_TENANT_ID",
+	"AWS_ACCESS_KEY_ID",
+	"AWS_REGION",
+	"AWS_SECRET_ACCESS_KEY",
+	"AWS_SESSION_TOKEN",
+	"GCP_PROJECT_NAME",
+	"GCP_PROJECT_NUMBER",
+	"GITHUB_TOKEN",
+	"GOOGLE_APPLICATION_CREDENTIALS",
+	"IMAGE_CONFIG",
+	"IMAGE_GID",
+	"IMAGE_HOME",
+	"IMAGE_UID",
+	"IMAGE_USER",
+	"IMAGE_VARIANT",
+	"LANGUAGE_VERSION",
+	"PULUMI_ACCESS_TOKEN",
+	"PULUMI_ORG",
+	"READ_ONLY_ROOT_FILESYSTEM",
+	"RUN_CONTAINER_TESTS",
+	"SBOM_OUTPUT",
+	"SDKS_TO_TEST",
+}
+
+// credentialNamePattern matches the names of environment variables that hold credentials.
+var credentialNamePattern = regexp.MustCompile(
+	`(?i)(^|_)(TOKEN|SECRET|PASSWORD|PASSWD|CREDENTIALS?|API_?KEY|ACCESS_KEY|PRIVATE_KEY|AUTH)(_|$)`)
+
+// credentialPatterns match credentials in environment variable values and file contents.
+var credentialPatterns = []struct {
+	name    string
+	pattern *regexp.Regexp
+}{
+	{"AWS access key ID", regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"AWS secret access key", regexp.MustCompile(`(?i)aws_secret_access_key\s*[=:]`)},
+	{"GitHub token", regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`)},
+	{"Pulumi access token", regexp.MustCompile(`\bpul-[0-9a-f]{40}\b`)},
+	{"npm token", regexp.MustCompile(`\bnpm_[A-Za-z0-9]{36}\b|_authToken\s*=`)},
+	{"PyPI token", regexp.MustCompile(`\bpypi-[A-Za-z0-9_-]{50,}`)},
+	{"Google OAuth access token", regexp.MustCompile(`\bya29\.[0-9A-Za-z_-]{20,}`)},
+	{"Google service account key", regexp.MustCompile(`"type"\s*:\s*"service_account"`)},
+	{"private key", regexp.MustCompile(`-----BEGIN ([A-Z]+ )*PRIVATE KEY-----`)},
+	{"registry auth", regexp.MustCompile(`"auth"\s*:\s*"[^"]+"`)},
+	{"password in URL", regexp.MustCompile(`\b[a-z][a-z0-9+.-]*://[^/\s:@]+:[^/\s@]+@`)},
+}
+
+// credentialFiles are the places in HOME where tools keep credentials and history. None of them
+// should be present in a published image.
+var credentialFiles = []string{
+	".aws",
+	".azure",
+	".bash_history",
+	".config/gcloud",
+	".docker/config.json",
+	".git-credentials",
+	".kube/config",
+	".netrc",
+	".node_repl_history",
+	".npmrc",
+	".pulumi/credentials.json",
+	".pypirc",
+	".python_history",
+	".sh_history",
+	".zsh_history",
+}
+
+// leakAllowlist lists what TestSecretLeakage expects to find for an image variant.
+type leakAllowlist struct {
+	// tmp are path.Match patterns for the entries of /tmp that are left behind by the image build.
+	tmp []string
+	// home are credential files, relative to HOME, that the image is expected to contain.
+	home []string
+}
+
+// newLeakAllowlist returns the allowlist for the image variant under test.
+func newLeakAllowlist(t *testing.T) leakAllowlist {
+	var allowlist leakAllowlist
+	// The nonroot image is built from the kitchen sink.
+	if hasJava(t) || isNonRoot(t) {
+		// The JVM's performance data, from running Gradle and Maven during the build.
+		allowlist.tmp = append(allowlist.tmp, "hsperfdata_*")
+	}
+	return allowlist
+}
+
+// leakSnapshot is what TestSecretLeakage scans. It is taken in TestMain, before any test runs, so
+// that the scan sees the environment, HOME and /tmp the way the image ships them, and not what
+// other tests write there.
+type leakSnapshot struct {
+	environ []string
+	home    string
+	// credentialFiles are the entries of credentialFiles that exist in HOME.
+	credentialFiles []string
+	// tmp are the names of the entries of /tmp.
+	tmp []string
+	// credentials maps the files with credentials in them to the kinds of credentials found.
+	credentials map[string][]string
+	// unreadable are the files that couldn't be read.
+	unreadable []string
+	// err is the first error taking the snapshot ran into.
+	err error
+}
+
+// pristine is the snapshot taken before any test ran. It is only taken inside the container, where
+// CI sets RUN_CONTAINER_TESTS, so that running a few tests elsewhere doesn't scan a developer's
+// HOME and /tmp first.
+var pristine *leakSnapshot
+
+func TestMain(m *testing.M) {
+	if os.Getenv("RUN_CONTAINER_TESTS") != "" {
+		snapshot := takeLeakSnapshot()
+		pristine = &snapshot
+	}
+	os.Exit(m.Run())
+}
+
+func takeLeakSnapshot() leakSnapshot {
+	snapshot := leakSnapshot{environ: os.Environ(), credentials: map[string][]string{}}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		snapshot.err = err
+		return snapshot
+	}
+	snapshot.home = home
+
+	var roots []string
+	for _, name := range credentialFiles {
+		p := filepath.Join(home, name)
+		if _, err := os.Lstat(p); err == nil {
+			snapshot.credentialFiles = append(snapshot.credentialFiles, name)
+			roots = append(roots, p)
+		}
+	}
+	// Dotfiles directly in HOME, e.g. .bashrc and .gitconfig, and the configuration of the tools
+	// installed in the image, e.g. helm's repositories.
+	entries, err := os.ReadDir(home)
+	if err != nil {
+		snapshot.err = err
+		return snapshot
+	}
+	for _, entry := range entries {
+		if entry.Type().IsRegular() && !slices.Contains(credentialFiles, entry.Name()) {
+			roots = append(roots, filepath.Join(home, entry.Name()))
+		}
+	}
+	if _, err := os.Stat(filepath.Join(home, ".config")); err == nil {
+		roots = append(roots, filepath.Join(home, ".config"))
+	}
+
+	entries, err = os.ReadDir("/tmp")
+	if err != nil {
+		snapshot.err = err
+		return snapshot
+	}
+	for _, entry := range entries {
+		snapshot.tmp = append(snapshot.tmp, entry.Name())
+		roots = append(roots, filepath.Join("/tmp", entry.Name()))
+	}
+
+	for _, root := range roots {
+		if err := snapshot.scanFiles(root); err != nil {
+			snapshot.err = err
+			return snapshot
+		}
+	}
+	return snapshot
+}
+
+// scanFiles scans the file at root, or the files below it if it is a directory, for credentials.
+// Large files are skipped, credentials are kept in small configuration files.
+func (snapshot *leakSnapshot) scanFiles(root string) error {
+	const maxSize = 1 << 20
+
+	return filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrPermission) {
+			snapshot.unreadable = append(snapshot.unreadable, p)
+			return nil
+		}
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if info.Size() > maxSize {
+			return nil
+		}
+		data, err := os.ReadFile(p)
+		if errors.Is(err, fs.ErrPermission) {
+			snapshot.unreadable = append(snapshot.unreadable, p)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if kinds := findCredentials(string(data)); len(kinds) > 0 {
+			snapshot.credentials[p] = kinds
+		}
+		return nil
+	})
+}
+
+// findings returns the credentials and unreadable files the snapshot found at root, or below it.
+func (snapshot *leakSnapshot) findings(root string) []string {
+	var findings []string
+	for _, p := range snapshot.unreadable {
+		if isUnder(p, []string{root}) {
+			findings = append(findings, p+": unreadable")
+		}
+	}
+	for p, kinds := range snapshot.credentials {
+		if !isUnder(p, []string{root}) {
+			continue
+		}
+		for _, kind := range kinds {
+			findings = append(findings, p+": "+kind)
+		}
+	}
+	sort.Strings(findings)
+	return findings
+}
+
+// TestSecretLeakage scans the environment, the credential and history files in HOME, and /tmp for
+// credentials left behind by install scripts, and for files that shouldn't be in a published
+// image. Findings only name the location and the kind of credential, never the value.
+//
+// The scan uses the snapshot that TestMain takes before any test runs, see leakSnapshot.
+//
+// NOTE: This test is intended to be run inside the container.
+func TestSecretLeakage(t *testing.T) {
+	if pristine == nil {
+		t.Skip("The image is only scanned inside the container, with RUN_CONTAINER_TESTS set")
+	}
+	t.Parallel()
+	require.NoError(t, pristine.err, "taking the snapshot of the image")
+	allowlist := newLeakAllowlist(t)
+
+	t.Run("environment", func(t *testing.T) {
+		var findings []string
+		for _, kv := range pristine.environ {
+			name, value, _ := strings.Cut(kv, "=")
+			if slices.Contains(ciEnvVars, name) || value == "" {
+				continue
+			}
+			if credentialNamePattern.MatchString(name) {
+				findings = append(findings, name+": name suggests a credential")
+			}
+			for _, kind := range findCredentials(value) {
+				findings = append(findings, name+": "+kind)
+			}
+		}
+		require.Empty(t, findings, "credentials in the environment:\n%s", strings.Join(findings, "\n"))
+	})
+
+	t.Run("home", func(t *testing.T) {
+		var findings []string
+		for _, name := range pristine.credentialFiles {
+			if !slices.Contains(allowlist.home, name) {
+				findings = append(findings, filepath.Join(pristine.home, name)+": unexpected file")
+			}
+		}
+		findings = append(findings, pristine.findings(pristine.home)...)
+		require.Empty(t, findings, "credentials in %s:\n%s", pristine.home, strings.Join(findings, "\n"))
+	})
+
+	t.Run("tmp", func(t *testing.T) {
+		var findings []string
+		for _, name := range pristine.tmp {
+			if !matchesAny(allowlist.tmp, name) {
+				findings = append(findings, filepath.Join("/tmp", name)+": unexpected file")
+			}
+		}
+		findings = append(findings, pristine.findings("/tmp")...)
+		require.Empty(t, findings, "files left behind in /tmp:\n%s", strings.Join(findings, "\n"))
+	})
+}
+
+// findCredentials returns the kinds of credentials found in s.
+func findCredentials(s string) []string {
+	var kinds []string
+	for _, c := range credentialPatterns {
+		if c.pattern.MatchString(s) {
+			kinds = append(kinds, c.name)
+		}
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+func matchesAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
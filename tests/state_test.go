@@ -0,0 +1,78 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package containers
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	ptesting "github.com/pulumi/pulumi/sdk/v3/go/common/testing"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSharedState continues a stack from a DIY backend that was written elsewhere, the way teams
+// share one backend between pulumi/pulumi, the slim Debian and the UBI images.
+//
+// The state in testdata/state is meant to be written by an older Pulumi release with
+// testdata/state/generate.sh, see the README next to it. The checked-in fixture was written by
+// v3.259.0 and still has to be regenerated with an older release. The program is YAML so that
+// every image, including pulumi-base, can run it.
+//
+// NOTE: This test is intended to be run inside the container.
+func TestSharedState(t *testing.T) {
+	t.Parallel()
+
+	e := ptesting.NewEnvironment(t)
+	defer e.DeleteIfNotFailed()
+
+	backend := filepath.Join(e.RootPath, "backend")
+	copyTestDataDir(t, filepath.Join("testdata", "state", "backend"), backend)
+	e.SetBackend("file://" + filepath.ToSlash(backend))
+	e.CWD = filepath.Join(e.RootPath, "project")
+	copyTestDataDir(t, filepath.Join("testdata", "state", "project"), e.CWD)
+
+	e.RunCommand("pulumi", "stack", "select", "dev")
+
+	// Secrets in both the stack config and the checkpoint must decrypt.
+	stdout, _ := e.RunCommand("pulumi", "config", "get", "token")
+	require.Equal(t, "s3cr3t", strings.TrimSpace(stdout))
+	stdout, _ = e.RunCommand("pulumi", "stack", "output", "token", "--show-secrets")
+	require.Equal(t, "s3cr3t", strings.TrimSpace(stdout))
+
+	// The program is unchanged, so resolving the language plugin and running it must not produce
+	// any diffs against the existing state.
+	e.RunCommand("pulumi", "preview", "--expect-no-changes", "--non-interactive")
+
+	greeting := "hello from " + os.Getenv("IMAGE_VARIANT")
+	e.RunCommand("pulumi", "config", "set", "greeting", greeting)
+	e.RunCommand("pulumi", "up", "--yes", "--skip-preview", "--non-interactive")
+
+	stdout, _ = e.RunCommand("pulumi", "stack", "output", "greeting")
+	require.Equal(t, greeting, strings.TrimSpace(stdout))
+	stdout, _ = e.RunCommand("pulumi", "stack", "output", "token", "--show-secrets")
+	require.Equal(t, "s3cr3t", strings.TrimSpace(stdout))
+
+	// The update rewrote the checkpoint with this image's CLI. Secrets must still be encrypted at
+	// rest so that the next image, with the same passphrase, can continue the stack.
+	deployment := exportStack(t, e)
+	version, _ := e.RunCommand("pulumi", "version")
+	require.Equal(t, strings.TrimSpace(version), deployment.Manifest.Version)
+	checkpoint, err := os.ReadFile(filepath.Join(backend, ".pulumi", "stacks", "shared-state", "dev.json"))
+	require.NoError(t, err)
+	require.NotContains(t, string(checkpoint), "s3cr3t")
+	require.Contains(t, string(checkpoint), greeting)
+}
@@ -0,0 +1,87 @@
+// Copyright 2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package containers
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	ptesting "github.com/pulumi/pulumi/sdk/v3/go/common/testing"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLocalTemplates instantiates each template in testdata/templates with `pulumi new ./path`,
+// then installs and previews the generated project against a local file backend. Unlike
+// TestPulumiTemplateTests, this does not download templates and needs no cloud credentials.
+//
+// NOTE: This test is intended to be run inside the container.
+func TestLocalTemplates(t *testing.T) {
+	t.Parallel()
+
+	languageVersion := os.Getenv("LANGUAGE_VERSION") // Not set for kitchen sink
+
+	dirs, err := testdata.ReadDir("testdata/templates")
+	require.NoError(t, err)
+
+	for _, dir := range dirs {
+		sdk := dir.Name()
+		t.Run(sdk, func(t *testing.T) {
+			if !hasSDK(t, sdk) {
+				t.Skipf("Skipping %s template for images without its runtime", sdk)
+			}
+			if sdk == "csharp" && languageVersion == "6.0" {
+				// The template targets net8.0, which the .NET 6.0 SDK cannot build.
+				t.Skip("Skipping csharp template on .NET 6.0")
+			}
+			t.Parallel()
+
+			e := ptesting.NewEnvironment(t)
+			defer e.DeleteIfNotFailed()
+			e.SetBackend(e.LocalURL())
+
+			template := filepath.Join(e.RootPath, "template")
+			copyTestDataDir(t, filepath.Join("testdata", "templates", sdk), template)
+
+			project := fmt.Sprintf("catalog-%s", sdk)
+			greeting := fmt.Sprintf("hello from the %s template", sdk)
+			e.CWD = filepath.Join(e.RootPath, project)
+			require.NoError(t, os.MkdirAll(e.CWD, os.ModePerm))
+
+			// `--yes` accepts the defaults for every prompt, so `greeting` must be supplied
+			// explicitly for the program to see the non-default value.
+			e.RunCommand("pulumi", "new", template,
+				"--yes", "--force",
+				"--name", project,
+				"--stack", "dev",
+				"--config", "greeting="+greeting)
+
+			projectFile, err := os.ReadFile(filepath.Join(e.CWD, "Pulumi.yaml"))
+			require.NoError(t, err)
+			require.Contains(t, string(projectFile), "name: "+project)
+			require.NotContains(t, string(projectFile), "template:",
+				"the template section should be removed from the generated project")
+
+			stdout, _ := e.RunCommand("pulumi", "config", "get", "greeting")
+			require.Equal(t, greeting, strings.TrimSpace(stdout))
+
+			e.RunCommand("pulumi", "install")
+			stdout, _ = e.RunCommand("pulumi", "preview", "--non-interactive", "--diff")
+			require.Contains(t, stdout, greeting)
+		})
+	}
+}
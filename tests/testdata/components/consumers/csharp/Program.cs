@@ -0,0 +1,16 @@
+using System.Collections.Generic;
+using Pulumi;
+using Pulumi.__Package__;
+
+return await Deployment.RunAsync(() =>
+{
+    var greeter = new Greeter("greeter", new GreeterArgs
+    {
+        Name = "C#",
+    });
+
+    return new Dictionary<string, object?>
+    {
+        ["message"] = greeter.Message,
+    };
+});
@@ -0,0 +1,19 @@
+package main
+
+import (
+	"github.com/pulumi/pulumi-__PACKAGE__/sdk/go/__PACKAGE__"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+func main() {
+	pulumi.Run(func(ctx *pulumi.Context) error {
+		g, err := __PACKAGE__.NewGreeter(ctx, "greeter", &__PACKAGE__.GreeterArgs{
+			Name: pulumi.String("Go"),
+		})
+		if err != nil {
+			return err
+		}
+		ctx.Export("message", g.Message)
+		return nil
+	})
+}
@@ -0,0 +1,6 @@
+import pulumi
+import pulumi___PACKAGE__ as greeter
+
+g = greeter.Greeter("greeter", name="Python")
+
+pulumi.export("message", g.message)
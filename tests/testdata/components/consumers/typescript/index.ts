@@ -0,0 +1,5 @@
+import * as greeter from "@pulumi/__PACKAGE__";
+
+const g = new greeter.Greeter("greeter", { name: "TypeScript" });
+
+export const message = g.message;
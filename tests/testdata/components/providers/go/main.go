@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	p "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi-go-provider/infer"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+type GreeterArgs struct {
+	Name pulumi.StringInput `pulumi:"name"`
+}
+
+type Greeter struct {
+	pulumi.ResourceState
+	Message pulumi.StringOutput `pulumi:"message"`
+}
+
+func NewGreeter(ctx *pulumi.Context, name string, args GreeterArgs, opts ...pulumi.ResourceOption) (*Greeter, error) {
+	comp := &Greeter{}
+	err := ctx.RegisterComponentResource(p.GetTypeToken(ctx.Context()), name, comp, opts...)
+	if err != nil {
+		return nil, err
+	}
+	comp.Message = pulumi.Sprintf("Hello, %s from Go", args.Name)
+	if err := ctx.RegisterResourceOutputs(comp, pulumi.Map{"message": comp.Message}); err != nil {
+		return nil, err
+	}
+	return comp, nil
+}
+
+func main() {
+	provider, err := infer.NewProviderBuilder().
+		WithName("gogreeter").
+		WithComponents(infer.ComponentF(NewGreeter)).
+		Build()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err)
+		os.Exit(1)
+	}
+	if err := provider.Run(context.Background(), "gogreeter", "0.1.0"); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err)
+		os.Exit(1)
+	}
+}
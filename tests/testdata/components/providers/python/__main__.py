@@ -0,0 +1,6 @@
+from pulumi.provider.experimental import component_provider_host
+
+from greeter import Greeter
+
+if __name__ == "__main__":
+    component_provider_host(name="pygreeter", components=[Greeter])
@@ -0,0 +1,23 @@
+from typing import Optional, TypedDict
+
+import pulumi
+
+
+class GreeterArgs(TypedDict):
+    name: pulumi.Input[str]
+    """The name to greet."""
+
+
+class Greeter(pulumi.ComponentResource):
+    message: pulumi.Output[str]
+    """The greeting."""
+
+    def __init__(
+        self,
+        name: str,
+        args: GreeterArgs,
+        opts: Optional[pulumi.ResourceOptions] = None,
+    ) -> None:
+        super().__init__("pygreeter:index:Greeter", name, {}, opts)
+        self.message = pulumi.Output.concat("Hello, ", args["name"], " from Python")
+        self.register_outputs({"message": self.message})
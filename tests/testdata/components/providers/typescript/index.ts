@@ -0,0 +1,15 @@
+import * as pulumi from "@pulumi/pulumi";
+
+export interface GreeterArgs {
+    name: pulumi.Input<string>;
+}
+
+export class Greeter extends pulumi.ComponentResource {
+    public readonly message: pulumi.Output<string>;
+
+    constructor(name: string, args: GreeterArgs, opts?: pulumi.ComponentResourceOptions) {
+        super("tsgreeter:index:Greeter", name, args, opts);
+        this.message = pulumi.interpolate`Hello, ${args.name} from TypeScript`;
+        this.registerOutputs({ message: this.message });
+    }
+}
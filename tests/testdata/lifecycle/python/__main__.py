@@ -0,0 +1,67 @@
+import os
+from typing import Any, Optional
+
+import pulumi
+from pulumi.dynamic import (
+    CreateResult,
+    DiffResult,
+    ReadResult,
+    Resource,
+    ResourceProvider,
+    UpdateResult,
+)
+
+
+class LocalFileProvider(ResourceProvider):
+    """
+    A dynamic provider that manages a file on the local disk. Dynamic providers run inside the
+    program's language host, so no provider plugin has to be downloaded.
+    """
+
+    def create(self, props: dict[str, Any]) -> CreateResult:
+        with open(props["path"], "w") as f:
+            f.write(props["content"])
+        return CreateResult(id_=props["path"], outs=props)
+
+    def diff(self, _id: str, olds: dict[str, Any], news: dict[str, Any]) -> DiffResult:
+        replaces = ["path"] if olds["path"] != news["path"] else []
+        return DiffResult(
+            changes=olds["path"] != news["path"] or olds["content"] != news["content"],
+            replaces=replaces,
+            delete_before_replace=True,
+        )
+
+    def update(self, _id: str, _olds: dict[str, Any], news: dict[str, Any]) -> UpdateResult:
+        with open(news["path"], "w") as f:
+            f.write(news["content"])
+        return UpdateResult(outs=news)
+
+    def read(self, id_: str, props: dict[str, Any]) -> ReadResult:
+        with open(id_) as f:
+            content = f.read()
+        return ReadResult(id_=id_, outs={**props, "content": content})
+
+    def delete(self, id_: str, _props: dict[str, Any]) -> None:
+        if os.path.exists(id_):
+            os.remove(id_)
+
+
+class LocalFile(Resource):
+    path: pulumi.Output[str]
+    content: pulumi.Output[str]
+
+    def __init__(
+        self,
+        name: str,
+        path: pulumi.Input[str],
+        content: pulumi.Input[str],
+        opts: Optional[pulumi.ResourceOptions] = None,
+    ) -> None:
+        super().__init__(LocalFileProvider(), name, {"path": path, "content": content}, opts)
+
+
+config = pulumi.Config()
+file = LocalFile("file", path=config.require("path"), content=config.require("content"))
+
+pulumi.export("path", file.path)
+pulumi.export("content", file.content)
@@ -0,0 +1,56 @@
+import * as pulumi from "@pulumi/pulumi";
+import * as fs from "fs";
+
+interface LocalFileInputs {
+    path: string;
+    content: string;
+}
+
+// A dynamic provider that manages a file on the local disk. Dynamic providers run inside the
+// program's language host, so no provider plugin has to be downloaded.
+const localFileProvider: pulumi.dynamic.ResourceProvider = {
+    async create(inputs: LocalFileInputs): Promise<pulumi.dynamic.CreateResult> {
+        fs.writeFileSync(inputs.path, inputs.content);
+        return { id: inputs.path, outs: inputs };
+    },
+
+    async diff(id: string, olds: LocalFileInputs, news: LocalFileInputs): Promise<pulumi.dynamic.DiffResult> {
+        return {
+            changes: olds.path !== news.path || olds.content !== news.content,
+            replaces: olds.path !== news.path ? ["path"] : [],
+            deleteBeforeReplace: true,
+        };
+    },
+
+    async update(id: string, olds: LocalFileInputs, news: LocalFileInputs): Promise<pulumi.dynamic.UpdateResult> {
+        fs.writeFileSync(news.path, news.content);
+        return { outs: news };
+    },
+
+    async read(id: string, props: LocalFileInputs): Promise<pulumi.dynamic.ReadResult> {
+        return { id, props: { ...props, content: fs.readFileSync(id, "utf8") } };
+    },
+
+    async delete(id: string): Promise<void> {
+        fs.rmSync(id, { force: true });
+    },
+};
+
+class LocalFile extends pulumi.dynamic.Resource {
+    public readonly path!: pulumi.Output<string>;
+    public readonly content!: pulumi.Output<string>;
+
+    constructor(name: string, args: { path: pulumi.Input<string>; content: pulumi.Input<string> },
+        opts?: pulumi.CustomResourceOptions) {
+        super(localFileProvider, name, args, opts);
+    }
+}
+
+const config = new pulumi.Config();
+const file = new LocalFile("file", {
+    path: config.require("path"),
+    content: config.require("content"),
+});
+
+export const path = file.path;
+export const content = file.content;
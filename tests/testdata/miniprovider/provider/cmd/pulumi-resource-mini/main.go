@@ -0,0 +1,21 @@
+// pulumi-resource-mini is a miniature provider, used to check that the build environment can
+// build providers.
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"example.com/schema"
+
+	"github.com/pulumi/pulumi-mini/provider/pkg/version"
+)
+
+func main() {
+	spec, err := schema.PackageSpec{Name: "mini", Version: version.Version}.Marshal()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Println(string(spec))
+}
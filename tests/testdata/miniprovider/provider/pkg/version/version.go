@@ -0,0 +1,5 @@
+// Package version holds the provider version, which is set at build time.
+package version
+
+// Version is set with -ldflags by goreleaser.
+var Version = "0.0.0-dev"
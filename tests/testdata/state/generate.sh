@@ -0,0 +1,28 @@
+#!/usr/bin/env bash
+# Regenerates the shared-state fixture with an older Pulumi release. See README.md.
+set -euo pipefail
+
+version="${1:?usage: generate.sh <pulumi version, e.g. 3.100.0>}"
+here="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
+work="$(mktemp -d)"
+trap 'rm -rf "$work"' EXIT
+
+curl -fsSL https://get.pulumi.com | sh -s -- --version "$version" --install-root "$work/cli" --no-edit-path
+export PATH="$work/cli/bin:$PATH"
+export PULUMI_HOME="$work/home"
+export PULUMI_CONFIG_PASSPHRASE="correct horse battery staple"
+export PULUMI_SKIP_UPDATE_CHECK=true
+pulumi version
+
+rm -rf "$here/backend" "$here/project/Pulumi.dev.yaml"
+mkdir -p "$here/backend"
+pulumi login "file://$here/backend"
+cd "$here/project"
+pulumi stack init dev --non-interactive
+pulumi config set greeting hello
+pulumi config set --secret token s3cr3t
+pulumi up --yes --skip-preview --non-interactive
+
+# Only the checkpoint and the backend metadata are part of the fixture.
+find "$here/backend" -type f \( -name '*.bak' -o -name '*.attrs' \) -delete
+rm -rf "$here/backend/.pulumi/history" "$here/backend/.pulumi/backups" "$here/backend/.pulumi/locks"
@@ -0,0 +1,11 @@
+using System.Collections.Generic;
+using Pulumi;
+
+return await Deployment.RunAsync(() =>
+{
+    var config = new Config();
+    return new Dictionary<string, object?>
+    {
+        ["greeting"] = config.Require("greeting"),
+    };
+});
@@ -0,0 +1,11 @@
+package myproject;
+
+import com.pulumi.Pulumi;
+
+public class App {
+    public static void main(String[] args) {
+        Pulumi.run(ctx -> {
+            ctx.export("greeting", ctx.config().require("greeting"));
+        });
+    }
+}
@@ -0,0 +1,5 @@
+import pulumi
+
+config = pulumi.Config()
+
+pulumi.export("greeting", config.require("greeting"))
@@ -0,0 +1,5 @@
+import * as pulumi from "@pulumi/pulumi";
+
+const config = new pulumi.Config();
+
+export const greeting = config.require("greeting");